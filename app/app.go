@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
+	"typechan/engine"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
 )
 
 var (
@@ -15,8 +20,33 @@ var (
 	currentMode Mode
 	// Timeout duration for Timed mode
 	Timeout time.Duration = time.Second * 5 * 60
+	// Policy is the error policy applied to the test
+	Policy engine.ErrorPolicy = engine.Lenient
+	// SkipAhead makes space always jump to the next word
+	SkipAhead bool
+	// BackspaceWords allows backspacing into previous words
+	BackspaceWords bool
+	// VisibleLines is the number of lines of text visible at once, 0 for all
+	VisibleLines int = 5
 )
 
+// testOptions returns the engine options of the test, as set by the
+// package options.
+func testOptions() engine.Options {
+	return engine.Options{
+		Policy:         Policy,
+		SkipAhead:      SkipAhead,
+		BackspaceWords: BackspaceWords,
+		Width:          appWidth,
+	}
+}
+
+// CheckPolicy tells if the error policy can be applied along with the
+// other test options.
+func CheckPolicy() error {
+	return testOptions().Check()
+}
+
 type Mode int
 
 const (
@@ -24,16 +54,38 @@ const (
 	Timed
 )
 
+func (m Mode) String() string {
+	switch m {
+	case Sprint:
+		return "sprint"
+	case Timed:
+		return "timed"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
 // app is the page model of the program.
 // It keeps track of the page the user is currently on.
 type app struct {
 	currentPage Page
+	firstPage   Page // page the app starts on, the typing page if nil
+	source      TextSource
+	clock       Clock
 	error       error
+	lastResult  *Result        // result of the last test completed
+	seed        int64          // seed of the texts of the last test started
+	firstSeed   int64          // seed of the texts of the first test, picked by nextSeed if 0
+	sending     sync.WaitGroup // results being sent to the sinks
+	sessions    *sessionReporter
 }
 
 func (a *app) Init() tea.Cmd {
-	// starts on typing page
-	if err := a.changePage(newTypingPage(a)); err != nil {
+	page := a.firstPage
+	if page == nil {
+		// starts on typing page
+		page = newTypingPage(a)
+	}
+	if err := a.changePage(page); err != nil {
 		a.error = err
 		return tea.Quit
 	}
@@ -80,16 +132,61 @@ func (a *app) changePage(page Page) error {
 
 // New returns a new app instance.
 func New() *app {
-	return &app{}
+	return &app{clock: systemClock{}}
+}
+
+// SetClock sets the clock timing the tests, the system clock by default.
+func (a *app) SetClock(c Clock) {
+	a.clock = c
 }
 
-// Start starts the program with the given mode.
-func (a *app) Start(m Mode) {
+// Start starts the program with the given mode, taking texts from the
+// given source. Returns the result of the last test completed, or nil if
+// none was.
+func (a *app) Start(m Mode, s TextSource) (*Result, error) {
 	currentMode = m
+	a.source = s
+
+	sessions, err := newSessionReporter()
+	if err != nil {
+		return nil, err
+	}
+	a.sessions = sessions
+	defer a.sessions.end()
+
+	if err := a.run(); err != nil {
+		return nil, err
+	}
+	return a.lastResult, a.error
+}
+
+// ShowLeaderboard starts the program on the page listing the given
+// boards, which must not be empty.
+func (a *app) ShowLeaderboard(boards []LeaderboardBoard) error {
+	a.firstPage = newLeaderboardPage(a, boards)
+	if err := a.run(); err != nil {
+		return err
+	}
+	return a.error
+}
+
+// run runs the program until the user quits.
+func (a *app) run() error {
+	options := []tea.ProgramOption{}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		// keep stdout clean for the result, e.g. when piped, and style
+		// according to the terminal the program is shown on instead
+		output := termenv.NewOutput(os.Stderr)
+		lipgloss.SetColorProfile(output.ColorProfile())
+		lipgloss.SetHasDarkBackground(output.HasDarkBackground())
+		options = append(options, tea.WithOutput(os.Stderr))
+	}
 
-	p := tea.NewProgram(a)
+	p := tea.NewProgram(a, options...)
 	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error starting the program: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error starting the program: %w", err)
 	}
+	// don't cut off results still being sent on exit
+	a.sending.Wait()
+	return nil
 }
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+)
+
+const quoteCacheFile = "quotes.json"
+
+// cachingSource serves quotes from an on-disk cache of previously fetched
+// quotes, topping the cache up in the background from the underlying
+// source. Quotes typed recently, according to the history, or already
+// served in this session are not served again.
+type cachingSource struct {
+	source TextSource
+
+	mu          sync.Mutex
+	quotes      []quote         // cached quotes, oldest first
+	recent      map[string]bool // ids of quotes typed recently or served in this session
+	prefetching bool
+}
+
+func (s *cachingSource) next(ctx context.Context) (quote, error) {
+	defer s.prefetch(ctx)
+
+	rng := textRand(ctx)
+	if q, ok := s.take(rng); ok {
+		return q, nil
+	}
+
+	// nothing new in the cache, wait on the source instead
+	q, err := s.source.next(ctx)
+	if err != nil {
+		// offline, a repeated quote is better than none
+		if q, ok := s.takeAny(rng); ok {
+			return q, nil
+		}
+		return q, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(q)
+	s.recent[q.id()] = true
+	s.save() // the cache is best-effort, failing to persist it is no reason to fail the test
+	return q, nil
+}
+
+// take picks a random unseen quote from the cache and marks it as served.
+func (s *cachingSource) take(rng *rand.Rand) (quote, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unseen := s.unseen()
+	if len(unseen) == 0 {
+		return quote{}, false
+	}
+	q := unseen[rng.Intn(len(unseen))]
+	s.recent[q.id()] = true
+	return q, true
+}
+
+// takeAny returns a random quote from the cache passing the Filter,
+// seen or not.
+func (s *cachingSource) takeAny(rng *rand.Rand) (quote, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matching := []quote{}
+	for _, q := range s.quotes {
+		if Filter.matches(q) {
+			matching = append(matching, q)
+		}
+	}
+	if len(matching) == 0 {
+		return quote{}, false
+	}
+	return matching[rng.Intn(len(matching))], true
+}
+
+// unseen returns the cached quotes passing the Filter, that were not
+// typed recently nor served yet.
+func (s *cachingSource) unseen() []quote {
+	unseen := []quote{}
+	for _, q := range s.quotes {
+		if !s.recent[q.id()] && Filter.matches(q) {
+			unseen = append(unseen, q)
+		}
+	}
+	return unseen
+}
+
+// add adds a quote to the cache, evicting the oldest ones if full.
+func (s *cachingSource) add(q quote) {
+	for _, cached := range s.quotes {
+		if cached.id() == q.id() {
+			return
+		}
+	}
+
+	s.quotes = append(s.quotes, q)
+	if len(s.quotes) > quoteCacheSize {
+		s.quotes = s.quotes[len(s.quotes)-quoteCacheSize:]
+	}
+}
+
+// save writes the cache to disk.
+func (s *cachingSource) save() error {
+	return writeJSON(quoteCacheFile, s.quotes)
+}
+
+// prefetch tops up the cache with unseen quotes in the background,
+// stopping at the first failure e.g. when offline, or once ctx is done,
+// e.g. when the test fetching texts with it is over.
+func (s *cachingSource) prefetch(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.prefetching || len(s.unseen()) >= quotePrefetchCount {
+		return
+	}
+	s.prefetching = true
+
+	go func() {
+		// quotes prefetched aren't the test's to pick, see withTextRand
+		ctx, cancel := context.WithTimeout(withoutValues{ctx}, quotePrefetchTimeout)
+		defer cancel()
+
+		// bounded, in case the source keeps returning quotes already cached
+		for i := 0; i < quotePrefetchCount; i++ {
+			s.mu.Lock()
+			done := len(s.unseen()) >= quotePrefetchCount
+			s.mu.Unlock()
+			if done {
+				break
+			}
+
+			q, err := s.source.next(ctx)
+			if err != nil {
+				break
+			}
+			s.mu.Lock()
+			s.add(q)
+			s.mu.Unlock()
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.prefetching = false
+		s.save()
+	}()
+}
+
+// withoutValues is a context carrying none of the values of the context
+// it wraps, but done along with it.
+type withoutValues struct {
+	context.Context
+}
+
+func (withoutValues) Value(key any) any {
+	return nil
+}
+
+// newCachingSource returns a cachingSource wrapping the given source,
+// with the cache loaded from disk. The cache is topped up once quotes
+// are served from it.
+func newCachingSource(source TextSource) (*cachingSource, error) {
+	s := &cachingSource{
+		source: source,
+		quotes: []quote{},
+		recent: map[string]bool{},
+	}
+
+	if err := readJSON(quoteCacheFile, &s.quotes); err != nil {
+		return nil, err
+	}
+	for i := range s.quotes {
+		s.quotes[i].length = len(s.quotes[i].Text)
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	if len(history) > recentHistorySize {
+		history = history[len(history)-recentHistorySize:]
+	}
+	for _, r := range history {
+		for _, text := range r.Texts {
+			s.recent[text.ID] = true
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingSource serves a quote, then blocks until the context of the
+// fetch is done.
+type blockingSource struct {
+	served  bool
+	stopped chan bool // receives whether the fetch had a text picker, once stopped
+}
+
+func (s *blockingSource) next(ctx context.Context) (quote, error) {
+	if !s.served {
+		s.served = true
+		return quote{Text: headlessText, length: len(headlessText)}, nil
+	}
+	<-ctx.Done()
+	s.stopped <- ctx.Value(textRandKey{}) != nil
+	return quote{}, ctx.Err()
+}
+
+func TestCachePrefetchStops(t *testing.T) {
+	keepOptions(t)
+	Filter = TextFilter{}
+	source := &blockingSource{stopped: make(chan bool, 1)}
+	s, err := newCachingSource(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the test fetching texts is over once the first one is served
+	ctx, stop := context.WithCancel(withTextRand(context.Background(), 1))
+	if _, err := s.next(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	select {
+	case picker := <-source.stopped:
+		if picker {
+			t.Error("got the text picker of the test prefetching")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("prefetch still running once the test is over")
+	}
+
+	// the cache is saved before the prefetch is done
+	for prefetching := true; prefetching; time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		prefetching = s.prefetching
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options of the HTTP client used to query remote text sources.
+var (
+	// QuotableURL is the base URL of the quotable API
+	QuotableURL string = "https://api.quotable.io"
+	// Proxy is the URL of the proxy to send requests through,
+	// defaults to the HTTP_PROXY and HTTPS_PROXY environment variables
+	Proxy string
+	// CAFile is a PEM file of additional certificate authorities to trust
+	CAFile string
+	// HTTPTimeout is the time limit of a single request
+	HTTPTimeout time.Duration = 10 * time.Second
+)
+
+// quotableClient queries quotes from the quotable API, retrying failed
+// requests with exponential backoff.
+type quotableClient struct {
+	baseURL    string
+	http       *http.Client
+	maxRetries int
+	backoff    time.Duration // delay before the first retry, doubled on every retry after
+	maxBackoff time.Duration
+}
+
+// newHTTPClient returns an HTTP client set up according to the client
+// options.
+func newHTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if Proxy != "" {
+		proxyURL, err := url.Parse(Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(CAFile)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &http.Client{Transport: transport, Timeout: HTTPTimeout}, nil
+}
+
+// newQuotableClient returns a new instance of quotableClient, set up
+// according to the client options.
+func newQuotableClient() (*quotableClient, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &quotableClient{
+		baseURL:    strings.TrimRight(QuotableURL, "/"),
+		http:       client,
+		maxRetries: httpMaxRetries,
+		backoff:    httpBackoff,
+		maxBackoff: httpMaxBackoff,
+	}, nil
+}
+
+// retryableError is an error worth retrying the request for.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// randomQuote queries a random quote from the API.
+func (c *quotableClient) randomQuote(ctx context.Context) (quote, error) {
+	query := url.Values{}
+	if Filter.MinLength > 0 {
+		query.Set("minLength", strconv.Itoa(Filter.MinLength))
+	}
+	if Filter.MaxLength > 0 {
+		query.Set("maxLength", strconv.Itoa(Filter.MaxLength))
+	}
+	if len(Filter.Authors) > 0 {
+		authors := []string{}
+		for _, author := range Filter.Authors {
+			authors = append(authors, slugify(author))
+		}
+		query.Set("author", strings.Join(authors, "|"))
+	}
+	if len(Filter.Tags) > 0 {
+		query.Set("tags", strings.Join(Filter.Tags, "|"))
+	}
+
+	var q quote
+	err := c.withRetries(ctx, func() error {
+		return c.get(ctx, "/random?"+query.Encode(), &q)
+	})
+	if err != nil {
+		return q, err
+	}
+
+	q.Text, q.length = processText(q.Text)
+	return q, nil
+}
+
+// withRetries calls f until it succeeds, fails with an error that is not
+// retryable, or the maximum number of retries is reached.
+func (c *quotableClient) withRetries(ctx context.Context, f func() error) error {
+	backoff := c.backoff
+	for retry := 0; ; retry++ {
+		err := f()
+		if _, ok := err.(*retryableError); !ok || retry >= c.maxRetries {
+			return err
+		}
+
+		// wait with jitter, so that clients don't retry in lockstep
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+// get sends a GET request to the path of the API, and decodes the JSON
+// response into v.
+func (c *quotableClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// drain the body so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+
+		err := fmt.Errorf("API returns code %v: %s", resp.StatusCode, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return &retryableError{err}
+		}
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &retryableError{err}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a quotableClient querying the given test server,
+// retrying quickly.
+func newTestClient(server *httptest.Server) *quotableClient {
+	return &quotableClient{
+		baseURL:    server.URL,
+		http:       server.Client(),
+		maxRetries: httpMaxRetries,
+		backoff:    time.Millisecond,
+		maxBackoff: 4 * time.Millisecond,
+	}
+}
+
+// quotableStandIn stands in for the quotable API, answering the requests
+// with the given status codes in turn, then with a quote.
+type quotableStandIn struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+}
+
+func (s *quotableStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+
+	if len(s.requests) <= len(s.statuses) {
+		w.WriteHeader(s.statuses[len(s.requests)-1])
+		io.WriteString(w, strings.Repeat("error page ", 100))
+		return
+	}
+	io.WriteString(w, `{"_id":"q1","content":"It’s a quote.","author":"Someone","tags":["famous-quotes"]}`)
+}
+
+func (s *quotableStandIn) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func TestRandomQuoteRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		wantErr  bool
+	}{
+		{"success", nil, 1, false},
+		{"server error then success", []int{500, 503}, 3, false},
+		{"too many requests then success", []int{429}, 2, false},
+		{"client error", []int{404}, 1, true},
+		{"bad request", []int{400}, 1, true},
+		{"server errors past the retries", []int{500, 500, 500, 500, 500, 500}, httpMaxRetries + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn := &quotableStandIn{statuses: tt.statuses}
+			server := httptest.NewServer(standIn)
+			defer server.Close()
+
+			q, err := newTestClient(server).randomQuote(context.Background())
+			if got := standIn.requestCount(); got != tt.requests {
+				t.Errorf("got %d requests, want %d", got, tt.requests)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.ID != "q1" || q.Text != "It's a quote." || q.length != len(q.Text) || q.Author != "Someone" {
+				t.Errorf("got quote %+v", q)
+			}
+		})
+	}
+}
+
+func TestRandomQuoteCancelledDuringBackoff(t *testing.T) {
+	standIn := &quotableStandIn{statuses: []int{503, 503, 503, 503, 503}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.backoff = time.Hour
+	client.maxBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.randomQuote(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("gave up after %v", elapsed)
+	}
+	if got := standIn.requestCount(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+// trackingBody records how much of a response body was read, and
+// whether it was closed.
+type trackingBody struct {
+	io.ReadCloser
+	read   int
+	closed bool
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += n
+	return n, err
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return b.ReadCloser.Close()
+}
+
+// trackingTransport wraps the bodies of the responses in trackingBody.
+type trackingTransport struct {
+	transport http.RoundTripper
+	bodies    []*trackingBody
+}
+
+func (t *trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body := &trackingBody{ReadCloser: resp.Body}
+	resp.Body = body
+	t.bodies = append(t.bodies, body)
+	return resp, nil
+}
+
+func TestRandomQuoteDrainsErrorBodies(t *testing.T) {
+	standIn := &quotableStandIn{statuses: []int{500, 404}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	client := newTestClient(server)
+	transport := &trackingTransport{transport: client.http.Transport}
+	client.http = &http.Client{Transport: transport}
+
+	if _, err := client.randomQuote(context.Background()); err == nil {
+		t.Fatal("got no error")
+	}
+	if len(transport.bodies) != 2 {
+		t.Fatalf("got %d responses, want 2", len(transport.bodies))
+	}
+	for i, body := range transport.bodies {
+		if want := len(strings.Repeat("error page ", 100)); body.read != want {
+			t.Errorf("response %d: read %d bytes of the body, want %d", i, body.read, want)
+		}
+		if !body.closed {
+			t.Errorf("response %d: body not closed", i)
+		}
+	}
+}
+
+func TestRandomQuoteFilterQuery(t *testing.T) {
+	defer func(f TextFilter) { Filter = f }(Filter)
+
+	tests := []struct {
+		name   string
+		filter TextFilter
+		want   string
+	}{
+		{"none", TextFilter{}, ""},
+		{"lengths", TextFilter{MinLength: 50, MaxLength: 200}, "maxLength=200&minLength=50"},
+		{"authors", TextFilter{Authors: []string{"Albert Einstein", "Mark Twain"}}, "author=albert-einstein%7Cmark-twain"},
+		{"tags", TextFilter{Tags: []string{"wisdom", "famous-quotes"}}, "tags=wisdom%7Cfamous-quotes"},
+		{"difficulty only", TextFilter{MinDifficulty: 3, MaxDifficulty: 6}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn := &quotableStandIn{}
+			server := httptest.NewServer(standIn)
+			defer server.Close()
+
+			Filter = tt.filter
+			if _, err := newTestClient(server).randomQuote(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			req := standIn.requests[0]
+			if req.URL.Path != "/random" {
+				t.Errorf("got path %q, want /random", req.URL.Path)
+			}
+			if req.URL.RawQuery != tt.want {
+				t.Errorf("got query %q, want %q", req.URL.RawQuery, tt.want)
+			}
+		})
+	}
+}
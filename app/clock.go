@@ -0,0 +1,77 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Clock tells the time to all timing code of the app, so that time can
+// be controlled, e.g. by tests and replays.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Tick returns a command that sends the message returned by fn,
+	// given the time it's called at, once d has passed.
+	Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd
+}
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return tea.Tick(d, fn)
+}
+
+// ManualClock is a Clock that only moves when advanced. Ticks are due as
+// soon as they are asked for, whether their commands are run or not.
+type ManualClock struct {
+	now   time.Time
+	ticks []manualTick // pending ticks, earliest first
+}
+
+// manualTick is a tick pending on a ManualClock.
+type manualTick struct {
+	at time.Time
+	fn func(time.Time) tea.Msg
+}
+
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *ManualClock) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	tick := manualTick{at: c.now.Add(d), fn: fn}
+	i := sort.Search(len(c.ticks), func(i int) bool { return c.ticks[i].at.After(tick.at) })
+	c.ticks = append(c.ticks[:i], append([]manualTick{tick}, c.ticks[i:]...)...)
+	return nil
+}
+
+// Advance moves the clock forward by d, sending the messages of the ticks
+// that fall due on the way, in order. Ticks asked for while sending are
+// sent too if they fall due before the end. Stops at the first error
+// returned by send.
+func (c *ManualClock) Advance(d time.Duration, send func(tea.Msg) error) error {
+	end := c.now.Add(d)
+	for len(c.ticks) > 0 && !c.ticks[0].at.After(end) {
+		tick := c.ticks[0]
+		c.ticks = c.ticks[1:]
+		c.now = tick.at
+		if err := send(tick.fn(tick.at)); err != nil {
+			return err
+		}
+	}
+	c.now = end
+	return nil
+}
+
+// NewManualClock returns a new instance of ManualClock, set at the given time.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
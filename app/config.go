@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const configFile = "config.json"
+
+// LoadConfig reads the user's defaults for command-line flags from the
+// config file in the data directory, keyed by flag name. Each value is
+// given as the flag would be given on the command line, once or, for
+// lists, once per item.
+func LoadConfig() (map[string][]string, error) {
+	raw := map[string]any{}
+	if err := readJSON(configFile, &raw); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	config := map[string][]string{}
+	for name, value := range raw {
+		if items, ok := value.([]any); ok {
+			for _, item := range items {
+				config[name] = append(config[name], configValue(item))
+			}
+		} else {
+			config[name] = []string{configValue(value)}
+		}
+	}
+	return config, nil
+}
+
+// configValue formats a decoded JSON value the way it would be given on
+// the command line.
+func configValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
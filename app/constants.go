@@ -1,10 +1,13 @@
 package app
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
-const maxMistypedCount int = 10
 const quoteBufferSize int = 3
-const scrollTextHeight int = 3
+const minRefillLines int = 3 // lines left to type before more text is fetched in Timed mode
 
 const paddingX int = 10
 const paddingY int = 2
@@ -13,3 +16,38 @@ const minWindowWidth int = 50
 const red = lipgloss.Color("#cc001b")
 const green = lipgloss.Color("#5ac700")
 const grey = lipgloss.Color("#595959")
+
+const practiceWordCount int = 30
+const practiceBias float64 = 4 // higher values favour weak words more strongly
+
+const lessonWordCount int = 30
+const lessonMinPoolSize int = 10 // minimum number of real words before drills mix them in
+
+const quoteRetryInterval time.Duration = 5 * time.Second
+
+const httpMaxRetries int = 4
+const httpBackoff time.Duration = 500 * time.Millisecond
+const httpMaxBackoff time.Duration = 8 * time.Second
+
+const quoteCacheSize int = 200
+const quotePrefetchCount int = 10 // number of unseen quotes to keep in cache
+const quotePrefetchTimeout time.Duration = time.Minute
+const recentHistorySize int = 100 // number of past results whose texts are not repeated
+
+const sinkTimeout time.Duration = 30 * time.Second // to send a result to all the sinks
+
+const filterMaxAttempts int = 20 // texts to try before giving up on finding one matching the filter
+
+const replayWidth int = 80 // width texts are wrapped at when replaying tests
+
+const leaderboardSize int = 10           // top scores listed on each board
+const leaderboardMaxName int = 32        // length of the names results are submitted under
+const leaderboardMaxBody int64 = 1 << 20 // size of a submission
+const leaderboardMinLength int = 100     // of the text of a sprint
+const leaderboardMinKeys int = 50        // pressed in a test
+const leaderboardMaxTexts int = 100      // of a timed test
+
+const sessionTimeout time.Duration = 30 * time.Minute      // after which a session not heard of is no longer active
+const sessionReportTimeout time.Duration = 5 * time.Second // to report the events of a session, all told on exit
+const sessionMaxID int = 64                                // length of the IDs of sessions
+const sessionMaxPending int = 16                           // events waiting to be reported, dropped beyond
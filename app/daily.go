@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const dailyFile = "daily.json"
+
+// dailyDateFormat is the format of the dates of daily challenges.
+const dailyDateFormat = "2006-01-02"
+
+// dailyAttempt is the official attempt at the daily challenge of a date,
+// i.e. the first one started.
+type dailyAttempt struct {
+	Date        string  `json:"date"`
+	AdjustedWPM float64 `json:"adjustedWPM"`
+	Accuracy    float64 `json:"accuracy"`
+	Unfinished  bool    `json:"unfinished,omitempty"` // quit, failed or not over yet
+}
+
+// loadDailyAttempts reads the official attempts at daily challenges
+// from disk, oldest first.
+func loadDailyAttempts() ([]dailyAttempt, error) {
+	attempts := []dailyAttempt{}
+	if err := readJSON(dailyFile, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// dailyStreak returns the number of days in a row, up to the given date,
+// with an official attempt completed. A streak isn't broken until the
+// date is over, so it's counted from the day before if the date has no
+// attempt completed yet.
+func dailyStreak(attempts []dailyAttempt, date string) int {
+	taken := map[string]bool{}
+	for _, a := range attempts {
+		if !a.Unfinished {
+			taken[a.Date] = true
+		}
+	}
+
+	day, err := time.Parse(dailyDateFormat, date)
+	if err != nil {
+		return 0
+	}
+	if !taken[date] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for taken[day.Format(dailyDateFormat)] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// dailyText returns the text of the daily challenge of the given date,
+// the same for everyone: words of the embedded word list, picked at
+// random with the date as seed.
+func dailyText(date string) quote {
+	hash := fnv.New64a()
+	hash.Write([]byte(date))
+	rng := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	words := practiceWords()
+	picked := []string{}
+	for i := 0; i < practiceWordCount; i++ {
+		picked = append(picked, words[rng.Intn(len(words))])
+	}
+
+	q := quote{generated: true}
+	q.Text, q.length = processText(strings.Join(picked, " "))
+	q.ID = "daily-" + date
+	q.Source = "Daily challenge " + date
+	return q
+}
+
+// dailySource serves the text of the daily challenge, and keeps track of
+// the official attempts.
+type dailySource struct {
+	date     string
+	official bool // the test being taken is the official attempt
+}
+
+func (s *dailySource) next(ctx context.Context) (quote, error) {
+	return dailyText(s.date), nil
+}
+
+// onStart records the test as the official attempt of the day, unless
+// one was already started: once the text is seen, quitting and trying
+// again doesn't make for a fresh attempt.
+func (s *dailySource) onStart() error {
+	attempts, err := loadDailyAttempts()
+	if err != nil {
+		return err
+	}
+
+	s.official = false
+	for _, a := range attempts {
+		if a.Date == s.date {
+			return nil
+		}
+	}
+	s.official = true
+	return writeJSON(dailyFile, append(attempts, dailyAttempt{Date: s.date, Unfinished: true}))
+}
+
+// onResult records the result of the official attempt of the day.
+func (s *dailySource) onResult(wpm float64, accuracy float64) (string, error) {
+	attempts, err := loadDailyAttempts()
+	if err != nil {
+		return "", err
+	}
+
+	i := 0
+	for i < len(attempts) && attempts[i].Date != s.date {
+		i++
+	}
+	if !s.official && i < len(attempts) {
+		if attempts[i].Unfinished {
+			return fmt.Sprintf("Today's official attempt was left unfinished: this one doesn't count.\nStreak: %s.",
+				days(dailyStreak(attempts, s.date))), nil
+		}
+		return fmt.Sprintf("Today's official attempt was already taken, at %.2f WPM: this one doesn't count.\nStreak: %s.",
+			attempts[i].AdjustedWPM, days(dailyStreak(attempts, s.date))), nil
+	}
+
+	if i == len(attempts) {
+		attempts = append(attempts, dailyAttempt{})
+	}
+	attempts[i] = dailyAttempt{Date: s.date, AdjustedWPM: wpm, Accuracy: accuracy}
+	if err := writeJSON(dailyFile, attempts); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Official attempt at the daily challenge of %s recorded.\nStreak: %s.",
+		s.date, days(dailyStreak(attempts, s.date))), nil
+}
+
+// days returns the number of days, e.g. "1 day" or "3 days".
+func days(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// NewDailySource returns a TextSource that serves the text of today's
+// daily challenge, by the local date.
+func NewDailySource() TextSource {
+	return &dailySource{date: time.Now().Format(dailyDateFormat)}
+}
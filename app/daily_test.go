@@ -0,0 +1,143 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDailyText(t *testing.T) {
+	q := dailyText("2023-03-04")
+	if again := dailyText("2023-03-04"); !reflect.DeepEqual(again, q) {
+		t.Errorf("got %q, then %q on the same date", q.Text, again.Text)
+	}
+	if other := dailyText("2023-03-05"); other.Text == q.Text {
+		t.Errorf("got the same text %q on two dates", q.Text)
+	}
+
+	// everyone must get the same text, whatever their version
+	if want := "kill cover square suggest directly "; !strings.HasPrefix(q.Text, want) {
+		t.Errorf("got text %q, want it to start with %q", q.Text, want)
+	}
+	if n := len(strings.Fields(q.Text)); n != practiceWordCount {
+		t.Errorf("got %d words, want %d", n, practiceWordCount)
+	}
+	if q.ID != "daily-2023-03-04" || q.length != len(q.Text) || !q.generated {
+		t.Errorf("got quote %+v", q)
+	}
+}
+
+func TestDailyStreak(t *testing.T) {
+	completed := func(dates ...string) []dailyAttempt {
+		attempts := []dailyAttempt{}
+		for _, date := range dates {
+			attempts = append(attempts, dailyAttempt{Date: date, AdjustedWPM: 50, Accuracy: 95})
+		}
+		return attempts
+	}
+	unfinished := func(attempts []dailyAttempt, date string) []dailyAttempt {
+		return append(attempts, dailyAttempt{Date: date, Unfinished: true})
+	}
+
+	tests := []struct {
+		name     string
+		attempts []dailyAttempt
+		date     string
+		want     int
+	}{
+		{"no attempts", completed(), "2023-03-04", 0},
+		{"today only", completed("2023-03-04"), "2023-03-04", 1},
+		{"days in a row", completed("2023-03-02", "2023-03-03", "2023-03-04"), "2023-03-04", 3},
+		{"not taken yet today", completed("2023-03-02", "2023-03-03"), "2023-03-04", 2},
+		{"missed yesterday", completed("2023-03-01", "2023-03-02"), "2023-03-04", 0},
+		{"gap", completed("2023-02-27", "2023-03-01", "2023-03-02", "2023-03-03"), "2023-03-03", 3},
+		{"across months", completed("2023-02-27", "2023-02-28", "2023-03-01"), "2023-03-01", 3},
+		{"in any order", completed("2023-03-04", "2023-03-02", "2023-03-03"), "2023-03-04", 3},
+		{"later attempts", completed("2023-03-03", "2023-03-05"), "2023-03-03", 1},
+		{"unfinished today", unfinished(completed("2023-03-02", "2023-03-03"), "2023-03-04"), "2023-03-04", 2},
+		{"unfinished yesterday", unfinished(completed("2023-03-02", "2023-03-04"), "2023-03-03"), "2023-03-04", 1},
+		{"invalid date", completed("2023-03-04"), "tomorrow", 0},
+	}
+	for _, tt := range tests {
+		if got := dailyStreak(tt.attempts, tt.date); got != tt.want {
+			t.Errorf("%s: got a streak of %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDailyOfficialAttempt(t *testing.T) {
+	DataDir = t.TempDir()
+
+	// the first attempt is official from its first key, completed or not
+	s := &dailySource{date: "2023-03-04"}
+	if err := s.onStart(); err != nil {
+		t.Fatal(err)
+	}
+	attempts, err := loadDailyAttempts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0] != (dailyAttempt{Date: "2023-03-04", Unfinished: true}) {
+		t.Fatalf("got attempts %+v once started", attempts)
+	}
+
+	// quitting it and starting again doesn't count
+	s = &dailySource{date: "2023-03-04"}
+	if err := s.onStart(); err != nil {
+		t.Fatal(err)
+	}
+	message, err := s.onResult(80, 99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(message, "left unfinished") {
+		t.Errorf("got message %q for a second attempt", message)
+	}
+	if attempts, _ := loadDailyAttempts(); len(attempts) != 1 || !attempts[0].Unfinished {
+		t.Errorf("got attempts %+v after a second attempt", attempts)
+	}
+
+	// the next day, the official attempt is completed, then retaken
+	s = &dailySource{date: "2023-03-05"}
+	if err := s.onStart(); err != nil {
+		t.Fatal(err)
+	}
+	if message, err = s.onResult(50, 95); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(message, "recorded") || !strings.Contains(message, "Streak: 1 day") {
+		t.Errorf("got message %q for the official attempt", message)
+	}
+	if err := s.onStart(); err != nil {
+		t.Fatal(err)
+	}
+	if message, err = s.onResult(90, 100); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(message, "already taken, at 50.00 WPM") {
+		t.Errorf("got message %q for a second attempt", message)
+	}
+
+	attempts, _ = loadDailyAttempts()
+	want := []dailyAttempt{{Date: "2023-03-04", Unfinished: true}, {Date: "2023-03-05", AdjustedWPM: 50, Accuracy: 95}}
+	if len(attempts) != 2 || attempts[0] != want[0] || attempts[1] != want[1] {
+		t.Errorf("got attempts %+v, want %+v", attempts, want)
+	}
+}
+
+func TestDailyAttemptStartsAtFirstKey(t *testing.T) {
+	keepOptions(t)
+	h, err := NewHeadless(Sprint, &dailySource{date: "2023-03-04"}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts, _ := loadDailyAttempts(); len(attempts) != 0 {
+		t.Errorf("got attempts %+v before any key", attempts)
+	}
+	if err := h.Keys("k"); err != nil {
+		t.Fatal(err)
+	}
+	if attempts, _ := loadDailyAttempts(); len(attempts) != 1 || !attempts[0].Unfinished {
+		t.Errorf("got attempts %+v after the first key", attempts)
+	}
+}
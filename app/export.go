@@ -0,0 +1,366 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportFormats are the formats results can be imported from, besides
+// "auto" which detects the format from the content.
+var ImportFormats = []string{"json", "csv", "monkeytype"}
+
+// ExportHistory writes the results of all past tests in the given
+// format, see WriteResults.
+func ExportHistory(w io.Writer, format string) error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	return WriteResults(w, format, history)
+}
+
+// ImportHistory reads results in the given format and adds them to the
+// history. Results already in the history, i.e. of a test taken at the
+// same time in the same mode, are skipped so that importing the same
+// file twice is harmless. Returns the number of results imported and
+// skipped.
+func ImportHistory(r io.Reader, format string) (imported int, skipped int, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	if format == "auto" {
+		if format, err = detectImportFormat(data); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var results []Result
+	switch format {
+	case "json":
+		results, err = readResultsJSON(data)
+	case "csv":
+		results, err = readResultsCSV(data)
+	case "monkeytype":
+		results, err = readMonkeytypeCSV(data)
+	default:
+		err = fmt.Errorf("unknown import format %q, must be one of: auto, %s", format, strings.Join(ImportFormats, ", "))
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		return 0, 0, err
+	}
+	seen := map[string]bool{}
+	for _, r := range history {
+		seen[resultKey(r)] = true
+	}
+	for _, r := range results {
+		if seen[resultKey(r)] {
+			skipped++
+			continue
+		}
+		seen[resultKey(r)] = true
+		history = append(history, r)
+		imported++
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+	return imported, skipped, writeJSON(historyFile, history)
+}
+
+// resultKey identifies a result among others, by the time and mode of the test.
+func resultKey(r Result) string {
+	return r.Date.UTC().Format(time.RFC3339) + " " + r.Mode
+}
+
+// detectImportFormat tells the format of the data to import, from its
+// first line.
+func detectImportFormat(data []byte) (string, error) {
+	firstLine, _, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	header := strings.Split(strings.TrimSpace(string(firstLine)), ",")
+	switch {
+	case strings.HasPrefix(string(firstLine), "{"):
+		return "json", nil
+	case len(header) > 0 && header[0] == csvHeader[0]:
+		return "csv", nil
+	case containsAll(header, "wpm", "acc", "timestamp"):
+		return "monkeytype", nil
+	}
+	return "", fmt.Errorf("unrecognised format, must be one of: %s", strings.Join(ImportFormats, ", "))
+}
+
+// containsAll tells if the values contain all the wanted ones.
+func containsAll(values []string, wanted ...string) bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[v] = true
+	}
+	for _, w := range wanted {
+		if !set[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// readResultsJSON reads results written one JSON object per line.
+func readResultsJSON(data []byte) ([]Result, error) {
+	results := []Result{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		results = append(results, r)
+	}
+	return results, scanner.Err()
+}
+
+// csvRecord is a record of a CSV file, whose fields are looked up by
+// the name of their column.
+type csvRecord struct {
+	columns map[string]int
+	fields  []string
+	err     error // first error met parsing a field
+}
+
+// readCSV reads the records of CSV data with a header.
+func readCSV(data []byte) ([]csvRecord, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("missing CSV header")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	records := []csvRecord{}
+	for _, fields := range rows[1:] {
+		records = append(records, csvRecord{columns: columns, fields: fields})
+	}
+	return records, nil
+}
+
+// string returns the field of the named column, empty if there is none.
+func (r *csvRecord) string(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return r.fields[i]
+}
+
+// float returns the field of the named column as a number, 0 if empty.
+func (r *csvRecord) float(name string) float64 {
+	field := r.string(name)
+	if field == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(field, 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return f
+}
+
+// int returns the field of the named column as an integer, 0 if empty.
+func (r *csvRecord) int(name string) int {
+	return int(math.Round(r.float(name)))
+}
+
+// int64 returns the field of the named column as a 64-bit integer, 0 if
+// empty. Unlike int, it's exact beyond the precision of a float.
+func (r *csvRecord) int64(name string) int64 {
+	field := r.string(name)
+	if field == "" {
+		return 0
+	}
+	i, err := strconv.ParseInt(field, 10, 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return i
+}
+
+// bool returns the field of the named column as a boolean, false if empty.
+func (r *csvRecord) bool(name string) bool {
+	field := r.string(name)
+	if field == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(field)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return b
+}
+
+// readResultsCSV reads results written as CSV by WriteResults.
+func readResultsCSV(data []byte) ([]Result, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	for i, record := range records {
+		var r Result
+		date, err := time.Parse(time.RFC3339, record.string("date"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date: %w", i+1, err)
+		}
+		r.Date = date
+		r.Mode = record.string("mode")
+		r.ErrorPolicy = record.string("errorPolicy")
+		r.Layout = record.string("layout")
+		r.Elapsed = time.Duration(record.float("elapsedSeconds") * float64(time.Second))
+		r.Duration = time.Duration(record.float("durationSeconds") * float64(time.Second))
+		r.Failed = record.bool("failed")
+		r.SkipAhead = record.bool("skipAhead")
+		r.BackspaceWords = record.bool("backspaceWords")
+		r.Seed = record.int64("seed")
+
+		if ids := record.string("textIds"); ids != "" {
+			authors := strings.Split(record.string("authors"), "|")
+			sources := strings.Split(record.string("sources"), "|")
+			tags := strings.Split(record.string("tags"), "|")
+			for j, id := range strings.Split(ids, "|") {
+				text := TextInfo{ID: id}
+				if j < len(authors) {
+					text.Author = authors[j]
+				}
+				if j < len(sources) {
+					text.Source = sources[j]
+				}
+				if j < len(tags) && tags[j] != "" {
+					text.Tags = strings.Split(tags[j], ",")
+				}
+				r.Texts = append(r.Texts, text)
+			}
+		}
+
+		r.TotalKeysPressed = record.int("totalKeysPressed")
+		r.CorrectKeysPressed = record.int("correctKeysPressed")
+		r.UncorrectedErrors = record.int("uncorrectedErrors")
+		r.GrossWPM = record.float("grossWPM")
+		r.Accuracy = record.float("accuracy")
+		r.AdjustedWPM = record.float("adjustedWPM")
+		r.CPM = record.float("cpm")
+
+		r.Keystrokes.Backspaces = record.int("backspaces")
+		r.Keystrokes.MeanLatency = time.Duration(record.int("meanLatencyMs")) * time.Millisecond
+		r.Keystrokes.Missed = map[string]int{}
+		for _, pair := range strings.Fields(record.string("missed")) {
+			// the key itself may be a colon
+			sep := strings.LastIndex(pair, ":")
+			if sep < 0 {
+				return nil, fmt.Errorf("row %d: invalid missed %q, must be key:count", i+1, pair)
+			}
+			name, count := pair[:sep], pair[sep+1:]
+			n, err := strconv.Atoi(count)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid missed: %w", i+1, err)
+			}
+			r.Keystrokes.Missed[keyLetter(name)] = n
+		}
+		if flags := record.string("flags"); flags != "" {
+			r.Flags = strings.Split(flags, "|")
+		}
+
+		if record.err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, record.err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// keyLetter is the reverse of keyName.
+func keyLetter(name string) string {
+	switch name {
+	case "space":
+		return " "
+	case "enter":
+		return "\n"
+	}
+	return name
+}
+
+// readMonkeytypeCSV reads results exported from Monkeytype. Its metrics
+// are kept as they are rather than recomputed: the raw WPM as gross WPM,
+// the WPM as adjusted WPM. Since Monkeytype doesn't count keys pressed,
+// counts are taken from the characters of the final text instead: all
+// but missed ones are counted as pressed, correct ones as pressed
+// correctly, and the others as uncorrected errors.
+func readMonkeytypeCSV(data []byte) ([]Result, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	for i, record := range records {
+		var r Result
+		r.Date = time.UnixMilli(int64(record.float("timestamp")))
+		r.Mode = "sprint"
+		if record.string("mode") == "time" {
+			r.Mode = "timed"
+		}
+		r.Elapsed = time.Duration(record.float("testDuration") * float64(time.Second))
+		if r.Mode == "timed" {
+			// the time limit, in seconds
+			r.Duration = time.Duration(record.float("mode2") * float64(time.Second))
+		}
+		r.Failed = record.bool("bailedOut")
+		r.Texts = []TextInfo{{
+			ID:     "monkeytype-" + record.string("_id"),
+			Source: strings.TrimSpace("Monkeytype " + record.string("mode") + " " + record.string("mode2")),
+		}}
+
+		// correct;incorrect;extra;missed
+		var chars [4]int
+		for j, count := range strings.Split(record.string("charStats"), ";") {
+			if j < len(chars) {
+				chars[j], _ = strconv.Atoi(count)
+			}
+		}
+		r.TotalKeysPressed = chars[0] + chars[1] + chars[2]
+		r.CorrectKeysPressed = chars[0]
+		r.UncorrectedErrors = chars[1] + chars[2] + chars[3]
+
+		r.GrossWPM = record.float("rawWpm")
+		r.Accuracy = record.float("acc") / 100
+		r.AdjustedWPM = record.float("wpm")
+		r.CPM = r.GrossWPM * 5
+
+		if record.err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, record.err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+	"typechan/engine"
+)
+
+func TestResultsRoundTrip(t *testing.T) {
+	results := []Result{
+		{
+			Date:        time.Date(2023, time.March, 4, 10, 30, 0, 0, time.UTC),
+			Mode:        "sprint",
+			ErrorPolicy: "lenient",
+			Layout:      "colemak",
+			Elapsed:     42500 * time.Millisecond,
+			Texts:       []TextInfo{{ID: "abc", Author: "Albert Einstein", Source: "Relativity", Tags: []string{"science", "famous-quotes"}}},
+			Seed:        1681234567890123457, // beyond the precision of a float
+			SkipAhead:   true,
+
+			TotalKeysPressed:   120,
+			CorrectKeysPressed: 110,
+			UncorrectedErrors:  3,
+			Metrics:            engine.Metrics{GrossWPM: 33.88, Accuracy: 0.9167, AdjustedWPM: 31.06, CPM: 169.41},
+			Keystrokes: engine.KeystrokeSummary{
+				Backspaces:  7,
+				MeanLatency: 180 * time.Millisecond,
+				Missed:      map[string]int{":": 2, ";": 1, " ": 3, "\n": 1, "e": 4},
+			},
+		},
+		{
+			Date:        time.Date(2023, time.March, 5, 8, 0, 0, 0, time.UTC),
+			Mode:        "timed",
+			ErrorPolicy: "stop-on-word",
+			Elapsed:     30 * time.Second,
+			Failed:      true,
+			Duration:    30 * time.Second,
+			Texts:       []TextInfo{{ID: "one", Source: "Lesson 5: Symbols"}, {ID: "two", Tags: []string{"wisdom"}}},
+			Seed:        -42,
+
+			BackspaceWords: true,
+			Keystrokes:     engine.KeystrokeSummary{Missed: map[string]int{}},
+			Flags:          []string{"text pasted (12 letters)", "keys pressed too fast for a human (20 under 10ms apart)"},
+		},
+	}
+
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteResults(&b, format, results); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []Result
+			var err error
+			if format == "csv" {
+				got, err = readResultsCSV(b.Bytes())
+			} else {
+				got, err = readResultsJSON(b.Bytes())
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// compared as JSON, where nil and empty lists are the same
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(results)
+			if !bytes.Equal(gotJSON, wantJSON) {
+				t.Errorf("got %s\nwant %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestReadMonkeytypeCSV(t *testing.T) {
+	data := []byte(`_id,isPb,wpm,acc,rawWpm,consistency,charStats,mode,mode2,quoteLength,restartCount,testDuration,afkDuration,incompleteTestSeconds,lazyMode,blindMode,bailedOut,tags,timestamp
+64a1f0,true,85.6,97.5,88.4,80.1,214;4;1;2,time,30,-1,0,30,0,0,false,false,false,,1688371200000
+64a1f1,false,62.31,95,65.2,75,130;5;0;1,words,25,-1,1,24.07,0,0,false,false,true,,1688374800000
+`)
+	if format, err := detectImportFormat(data); err != nil || format != "monkeytype" {
+		t.Fatalf("got format %q, error %v", format, err)
+	}
+	got, err := readMonkeytypeCSV(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Result{
+		{
+			Date:               time.UnixMilli(1688371200000),
+			Mode:               "timed",
+			Elapsed:            30 * time.Second,
+			Duration:           30 * time.Second,
+			Texts:              []TextInfo{{ID: "monkeytype-64a1f0", Source: "Monkeytype time 30"}},
+			TotalKeysPressed:   219,
+			CorrectKeysPressed: 214,
+			UncorrectedErrors:  7,
+			Metrics:            engine.Metrics{GrossWPM: 88.4, Accuracy: 0.975, AdjustedWPM: 85.6, CPM: 442},
+		},
+		{
+			Date:               time.UnixMilli(1688374800000),
+			Mode:               "sprint",
+			Elapsed:            24070 * time.Millisecond,
+			Failed:             true,
+			Texts:              []TextInfo{{ID: "monkeytype-64a1f1", Source: "Monkeytype words 25"}},
+			TotalKeysPressed:   135,
+			CorrectKeysPressed: 130,
+			UncorrectedErrors:  6,
+			Metrics:            engine.Metrics{GrossWPM: 65.2, Accuracy: 0.95, AdjustedWPM: 62.31, CPM: 326},
+		},
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("got %s\nwant %s", gotJSON, wantJSON)
+	}
+}
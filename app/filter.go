@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TextFilter restricts the texts served by every text source.
+type TextFilter struct {
+	MinLength     int // ignored if 0
+	MaxLength     int // ignored if 0
+	Authors       []string
+	Tags          []string // texts must have at least one of the tags
+	MinDifficulty float64
+	MaxDifficulty float64 // ignored if 0
+}
+
+// Filter is the filter applied to all texts.
+var Filter = TextFilter{MinLength: 100}
+
+// Difficulties are the named difficulty ranges, see difficulty.
+var Difficulties = map[string][2]float64{
+	"easy":   {0, 3},
+	"medium": {3, 6},
+	"hard":   {6, 0},
+}
+
+// SetDifficulty restricts the filter to the named difficulty range.
+func (f *TextFilter) SetDifficulty(name string) error {
+	if name == "" || name == "any" {
+		f.MinDifficulty, f.MaxDifficulty = 0, 0
+		return nil
+	}
+
+	r, ok := Difficulties[name]
+	if !ok {
+		return fmt.Errorf("unknown difficulty %q, must be one of: any, easy, medium, hard", name)
+	}
+	f.MinDifficulty, f.MaxDifficulty = r[0], r[1]
+	return nil
+}
+
+// matches tells if the quote passes the filter. Generated texts have no
+// author nor tags, and their difficulty comes from what they are made of,
+// e.g. the keys a lesson drills, rather than being picked, so only the
+// length limits apply to them.
+func (f *TextFilter) matches(q quote) bool {
+	if q.length < f.MinLength || (f.MaxLength > 0 && q.length > f.MaxLength) {
+		return false
+	}
+	if q.generated {
+		return true
+	}
+
+	if len(f.Authors) > 0 {
+		found := false
+		for _, author := range f.Authors {
+			found = found || slugify(author) == slugify(q.Author)
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Tags) > 0 {
+		found := false
+		for _, tag := range f.Tags {
+			for _, quoteTag := range q.Tags {
+				found = found || slugify(tag) == slugify(quoteTag)
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	d := difficulty(q.Text)
+	return d >= f.MinDifficulty && (f.MaxDifficulty == 0 || d < f.MaxDifficulty)
+}
+
+// slugify turns a name into its lowercase, hyphenated form,
+// e.g. "Albert Einstein" into "albert-einstein".
+func slugify(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// commonWords is the set of words in the embedded word list.
+var commonWords = func() map[string]bool {
+	common := map[string]bool{}
+	for _, word := range practiceWords() {
+		common[word] = true
+	}
+	return common
+}()
+
+// difficulty scores how hard a text is to type, from 0 upwards: text
+// made of common words only scores below 1, everyday prose around 3,
+// while text full of rare words, punctuation, capitals and numbers
+// scores 6 and above.
+func difficulty(text string) float64 {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return 0
+	}
+	rare := 0
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }))
+		if word != "" && !commonWords[word] {
+			rare++
+		}
+	}
+
+	var punctuation, capitals, digits int
+	for _, r := range text {
+		switch {
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			punctuation++
+		case unicode.IsUpper(r):
+			capitals++
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+
+	letters := float64(len(text))
+	return 4*float64(rare)/float64(len(words)) +
+		40*float64(punctuation)/letters +
+		40*float64(capitals)/letters +
+		60*float64(digits)/letters
+}
+
+// filteringSource only serves the texts of the underlying source that
+// pass the Filter.
+type filteringSource struct {
+	source TextSource
+}
+
+func (s *filteringSource) next(ctx context.Context) (quote, error) {
+	for i := 0; i < filterMaxAttempts; i++ {
+		q, err := s.source.next(ctx)
+		if err != nil {
+			return q, err
+		}
+		if Filter.matches(q) {
+			return q, nil
+		}
+	}
+	return quote{}, fmt.Errorf("no text found matching the filters after %d attempts", filterMaxAttempts)
+}
+
+// generateText builds a text out of the given number of generated words,
+// adding or dropping words to fit the length limits of the Filter.
+func generateText(count int, nextWord func() string) quote {
+	words := []string{}
+	length := -1 // no space before the first word
+	for len(words) < count || length < Filter.MinLength {
+		word := nextWord()
+		if Filter.MaxLength > 0 && len(words) > 0 && length+1+len(word) > Filter.MaxLength {
+			break
+		}
+		words = append(words, word)
+		length += 1 + len(word)
+	}
+
+	q := quote{generated: true}
+	q.Text, q.length = processText(strings.Join(words, " "))
+	return q
+}
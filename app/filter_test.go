@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFilterMatches(t *testing.T) {
+	defer func(f TextFilter) { Filter = f }(Filter)
+	Filter = TextFilter{MinLength: 100}
+
+	quoted := quote{Text: "Imagination is more important than knowledge.", Author: "Albert Einstein", Tags: []string{"Famous Quotes"}}
+	quoted.length = len(quoted.Text)
+	generated := generateText(3, func() string { return "the" })
+
+	tests := []struct {
+		name      string
+		filter    TextFilter
+		quoted    bool
+		generated bool
+	}{
+		{"none", TextFilter{}, true, true},
+		{"too short", TextFilter{MinLength: 100}, false, true},
+		{"too long", TextFilter{MaxLength: 10}, false, false},
+		{"author", TextFilter{Authors: []string{"albert einstein"}}, true, true},
+		{"other author", TextFilter{Authors: []string{"Mark Twain"}}, false, true},
+		{"tag", TextFilter{Tags: []string{"wisdom", "famous-quotes"}}, true, true},
+		{"other tag", TextFilter{Tags: []string{"wisdom"}}, false, true},
+		{"easy", TextFilter{MaxDifficulty: 3}, false, true},
+		{"hard", TextFilter{MinDifficulty: 6}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(quoted); got != tt.quoted {
+				t.Errorf("quote: got %v, want %v", got, tt.quoted)
+			}
+			if got := tt.filter.matches(generated); got != tt.generated {
+				t.Errorf("generated text: got %v, want %v", got, tt.generated)
+			}
+		})
+	}
+}
+
+func TestFilterServesDrills(t *testing.T) {
+	DataDir = t.TempDir()
+	defer func(f TextFilter) { Filter = f }(Filter)
+	Filter = TextFilter{MinLength: 100, Tags: []string{"wisdom", "famous-quotes"}}
+	Filter.SetDifficulty("easy")
+
+	lessons, err := NewLessonSource(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, source := range map[string]TextSource{
+		"lesson":   lessons,
+		"practice": NewAdaptiveSource(),
+		"words":    newWordsSource(),
+	} {
+		q, err := (&filteringSource{source: source}).next(context.Background())
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if q.length < Filter.MinLength {
+			t.Errorf("%s: got a text of %d letters, want at least %d", name, q.length, Filter.MinLength)
+		}
+	}
+}
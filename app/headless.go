@@ -0,0 +1,226 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Headless drives the app without a terminal, for tests: keys are fed
+// in from scripts, time only moves when told to, and texts are fetched
+// synchronously. Commands returned by the pages, e.g. ticks, are not
+// run, their effects being emulated by the driver instead. Since keys
+// fed in without time moving look pasted, results of scripts typed at
+// once are flagged, see engine.CheckLog.
+//
+// The package options (Policy, Filter, SkipAhead...) apply as they do
+// in the program, and data is persisted into DataDir, which tests should
+// point to a temporary directory. Texts are picked with Seed, or with
+// headlessSeed if it isn't set, so that runs are the same every time.
+type Headless struct {
+	app   *app
+	clock *ManualClock
+}
+
+// headlessEpoch is the fake time headless drivers start at, so that
+// renders and results are the same on every run.
+var headlessEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// headlessSeed is the seed headless drivers pick the texts of their first
+// test with, unless Seed is set.
+const headlessSeed int64 = 1
+
+// NewHeadless returns a new instance of Headless, with the typing page
+// of the given mode started on texts from the given source, in a window
+// of the given width. Views are rendered with ANSI colours whatever the
+// terminal running the tests.
+func NewHeadless(m Mode, s TextSource, width int) (*Headless, error) {
+	lipgloss.SetColorProfile(termenv.ANSI)
+
+	h := &Headless{app: New(), clock: NewManualClock(headlessEpoch)}
+	h.app.SetClock(h.clock)
+	h.app.firstSeed = Seed
+	if h.app.firstSeed == 0 {
+		h.app.firstSeed = headlessSeed
+	}
+
+	currentMode = m
+	h.app.source = s
+	h.app.Update(tea.WindowSizeMsg{Width: width, Height: 40})
+	h.app.Init()
+	if h.app.error != nil {
+		return nil, h.app.error
+	}
+	return h, nil
+}
+
+// headlessKeys are the special keys that can be used in scripts, by name.
+var headlessKeys = map[string]tea.KeyMsg{
+	"backspace":     {Type: tea.KeyBackspace},
+	"alt+backspace": {Type: tea.KeyBackspace, Alt: true},
+	"ctrl+h":        {Type: tea.KeyCtrlH},
+	"ctrl+w":        {Type: tea.KeyCtrlW},
+	"enter":         {Type: tea.KeyEnter},
+	"space":         {Type: tea.KeySpace},
+	"tab":           {Type: tea.KeyTab},
+	"esc":           {Type: tea.KeyEsc},
+	"ctrl+c":        {Type: tea.KeyCtrlC},
+	"up":            {Type: tea.KeyUp},
+	"down":          {Type: tea.KeyDown},
+	"left":          {Type: tea.KeyLeft},
+	"right":         {Type: tea.KeyRight},
+}
+
+// Keys feeds the keys of a script in, one at a time. Letters are typed
+// as they are, spaces and newlines as the space and enter keys, while
+// special keys are named between angle brackets, e.g.
+// "helo<backspace>lo world<enter>". An unknown key name is an error.
+func (h *Headless) Keys(script string) error {
+	for script != "" {
+		var msg tea.KeyMsg
+		if name, rest, ok := strings.Cut(script[1:], ">"); script[0] == '<' && ok {
+			if msg, ok = headlessKeys[name]; !ok {
+				return fmt.Errorf("unknown key <%s>", name)
+			}
+			script = rest
+		} else {
+			switch script[0] {
+			case ' ':
+				msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+			case '\n':
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			default:
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{rune(script[0])}}
+			}
+			script = script[1:]
+		}
+
+		if err := h.send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Advance moves time forward by the given duration, delivering the
+// ticks falling due on the way, e.g. timing out the test in Timed mode.
+func (h *Headless) Advance(d time.Duration) error {
+	return h.clock.Advance(d, h.send)
+}
+
+// Resize resizes the window to the given width.
+func (h *Headless) Resize(width int) error {
+	return h.send(tea.WindowSizeMsg{Width: width, Height: 40})
+}
+
+// send feeds a message in, then delivers the texts fetched meanwhile.
+func (h *Headless) send(msg tea.Msg) error {
+	h.app.Update(msg)
+	if h.app.error != nil {
+		return h.app.error
+	}
+
+	if page, ok := h.app.currentPage.(*typingPage); ok && page.quoteFetcher.fetching {
+		if msg := page.quoteFetcher.next(); msg != nil {
+			return h.send(msg)
+		}
+	}
+	if page, ok := h.app.currentPage.(*resultPage); ok && page.sending {
+		return h.send(page.waitSent())
+	}
+	return nil
+}
+
+// View returns the current render of the app.
+func (h *Headless) View() string {
+	return h.app.View()
+}
+
+// HeadlessState is a snapshot of the state of a test driven by Headless.
+type HeadlessState struct {
+	Finished bool // the result page is shown
+
+	// typing page
+	Typed      int // number of letters typed
+	Mistyped   int // number of mistypes stacked on the cursor
+	WordInput  string
+	Paused     bool
+	FetchError error
+
+	// both pages
+	Elapsed            time.Duration
+	Failed             bool
+	TotalKeysPressed   int
+	CorrectKeysPressed int
+	UncorrectedErrors  int
+
+	// result page
+	GrossWPM    float64
+	Accuracy    float64
+	AdjustedWPM float64
+	CPM         float64
+}
+
+// State returns a snapshot of the state of the test.
+func (h *Headless) State() HeadlessState {
+	switch page := h.app.currentPage.(type) {
+	case *typingPage:
+		totalKeysPressed, correctKeysPressed := page.test.KeysPressed()
+		return HeadlessState{
+			Typed:              page.textarea.Typed(),
+			Mistyped:           page.textarea.Mistyped(),
+			WordInput:          page.test.WordInput(),
+			Paused:             page.paused,
+			FetchError:         page.fetchError,
+			Elapsed:            page.stopWatch.elapsed(),
+			Failed:             page.test.Failed(),
+			TotalKeysPressed:   totalKeysPressed,
+			CorrectKeysPressed: correctKeysPressed,
+			UncorrectedErrors:  page.textarea.UncorrectedErrors(),
+		}
+	case *resultPage:
+		r := page.result
+		return HeadlessState{
+			Finished:           true,
+			Elapsed:            r.Elapsed,
+			Failed:             r.Failed,
+			TotalKeysPressed:   r.TotalKeysPressed,
+			CorrectKeysPressed: r.CorrectKeysPressed,
+			UncorrectedErrors:  r.UncorrectedErrors,
+			GrossWPM:           r.GrossWPM,
+			Accuracy:           r.Accuracy,
+			AdjustedWPM:        r.AdjustedWPM,
+			CPM:                r.CPM,
+		}
+	}
+	return HeadlessState{}
+}
+
+// CheckGolden compares the current view against the golden file at the
+// given path, returning an error showing both if they differ. If update
+// is true, the golden file is written with the current view instead.
+func (h *Headless) CheckGolden(path string, update bool) error {
+	view := []byte(h.View())
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, view, 0o644)
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(view, golden) {
+		return fmt.Errorf("view differs from %s\n--- got\n%s\n--- want\n%s", path, view, golden)
+	}
+	return nil
+}
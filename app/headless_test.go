@@ -0,0 +1,179 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"typechan/engine"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const headlessText = "the quick brown fox jumps over the lazy dog"
+
+// typeByHand feeds the keys of a script in one at a time, moving time
+// forward between them at uneven intervals, so that the result isn't
+// flagged as pasted or typed by a machine.
+func typeByHand(t *testing.T, h *Headless, script string) {
+	t.Helper()
+	for i := 0; script != ""; i++ {
+		key := script[:1]
+		if name, _, ok := strings.Cut(script[1:], ">"); script[0] == '<' && ok {
+			key = "<" + name + ">"
+		}
+		script = script[len(key):]
+
+		if err := h.Keys(key); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Advance(time.Duration(150+(i*37)%110) * time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkGolden compares the view of the driver against the golden file of
+// the given name in testdata.
+func checkGolden(t *testing.T, h *Headless, name string) {
+	t.Helper()
+	if err := h.CheckGolden(filepath.Join("testdata", name+".golden"), *update); err != nil {
+		t.Error(err)
+	}
+}
+
+// keepOptions restores the package options set by a test once it's over,
+// as well as the colour profile set by NewHeadless, and points DataDir to
+// a temporary directory.
+func keepOptions(t *testing.T) {
+	policy, skipAhead, timeout, seed, filter := Policy, SkipAhead, Timeout, Seed, Filter
+	dataDir, mode, profile := DataDir, currentMode, lipgloss.ColorProfile()
+	t.Cleanup(func() {
+		Policy, SkipAhead, Timeout, Seed, Filter = policy, skipAhead, timeout, seed, filter
+		DataDir, currentMode = dataDir, mode
+		lipgloss.SetColorProfile(profile)
+	})
+	DataDir = t.TempDir()
+}
+
+// newTestHeadless returns a headless driver on the given texts.
+func newTestHeadless(t *testing.T, m Mode, texts ...string) *Headless {
+	t.Helper()
+	h, err := NewHeadless(m, NewTextsSource(texts...), 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHeadlessSprint(t *testing.T) {
+	keepOptions(t)
+	h := newTestHeadless(t, Sprint, headlessText)
+	typeByHand(t, h, "the quixk<backspace><backspace>ck brwn")
+	checkGolden(t, h, "sprint_typing")
+
+	typeByHand(t, h, "<backspace><backspace>own fox jumps over the lazy dog")
+	state := h.State()
+	if !state.Finished || state.Failed || state.UncorrectedErrors != 0 {
+		t.Errorf("got state %+v once the text is typed", state)
+	}
+	checkGolden(t, h, "sprint_result")
+}
+
+func TestHeadlessTimed(t *testing.T) {
+	keepOptions(t)
+	Timeout = 15 * time.Second
+	h := newTestHeadless(t, Timed, headlessText, "pack my box with five dozen liquor jugs")
+
+	typeByHand(t, h, headlessText+"\npack my box")
+	checkGolden(t, h, "timed_typing")
+
+	if err := h.Advance(Timeout); err != nil {
+		t.Fatal(err)
+	}
+	state := h.State()
+	if !state.Finished || state.Elapsed != Timeout {
+		t.Errorf("got state %+v once timed out", state)
+	}
+	checkGolden(t, h, "timed_result")
+}
+
+func TestHeadlessErrorPolicies(t *testing.T) {
+	for _, name := range engine.ErrorPolicyNames() {
+		t.Run(name, func(t *testing.T) {
+			keepOptions(t)
+			policy, err := engine.ParseErrorPolicy(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			Policy = policy
+			h := newTestHeadless(t, Sprint, headlessText)
+
+			typeByHand(t, h, "the quixk<backspace><backspace>ck brown fox")
+			checkGolden(t, h, "policy_"+name)
+		})
+	}
+}
+
+func TestHeadlessSkipAhead(t *testing.T) {
+	keepOptions(t)
+	SkipAhead = true
+	h := newTestHeadless(t, Sprint, headlessText)
+
+	typeByHand(t, h, "the quik brownn fx jumps")
+	if state := h.State(); state.UncorrectedErrors != 5 {
+		t.Errorf("got %d errors, want 5", state.UncorrectedErrors)
+	}
+	checkGolden(t, h, "skip_ahead")
+}
+
+func TestHeadlessResize(t *testing.T) {
+	keepOptions(t)
+	h := newTestHeadless(t, Sprint, headlessText+" "+headlessText)
+	typeByHand(t, h, "the quick brown fox jumps ovr")
+	before := h.State()
+
+	for _, width := range []int{40, 120, 60} {
+		if err := h.Resize(width); err != nil {
+			t.Fatal(err)
+		}
+		if state := h.State(); state != before {
+			t.Errorf("width %d: got state %+v, want %+v", width, state, before)
+		}
+		checkGolden(t, h, fmt.Sprintf("resize_%d", width))
+	}
+}
+
+func TestHeadlessSeed(t *testing.T) {
+	keepOptions(t)
+	Seed = 0
+
+	views := []string{}
+	for i := 0; i < 2; i++ {
+		h, err := NewHeadless(Sprint, newWordsSource(), 80)
+		if err != nil {
+			t.Fatal(err)
+		}
+		views = append(views, h.View())
+	}
+	if views[0] != views[1] {
+		t.Errorf("got different texts picked at random:\n%s\n%s", views[0], views[1])
+	}
+	if Seed != 0 {
+		t.Errorf("got Seed set to %d by the driver", Seed)
+	}
+
+	// the seed set picks other texts
+	Seed = 42
+	h, err := NewHeadless(Sprint, newWordsSource(), 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.View() == views[0] {
+		t.Errorf("got the same texts picked with seed 42 as without")
+	}
+}
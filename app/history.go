@@ -0,0 +1,38 @@
+package app
+
+const historyFile = "history.json"
+
+// loadHistory reads the results of all past tests from disk, oldest first.
+func loadHistory() ([]Result, error) {
+	history := []Result{}
+	if err := readJSON(historyFile, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// bestOnText returns the best result among the past tests on the given
+// text alone, and false if there is none. Failed and flagged results
+// don't count.
+func bestOnText(history []Result, id string) (Result, bool) {
+	var best Result
+	found := false
+	for _, r := range history {
+		if !r.counts() || len(r.Texts) != 1 || r.Texts[0].ID != id {
+			continue
+		}
+		if !found || r.AdjustedWPM > best.AdjustedWPM {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// appendHistory adds the result to the history on disk.
+func appendHistory(r Result) error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	return writeJSON(historyFile, append(history, r))
+}
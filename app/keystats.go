@@ -0,0 +1,110 @@
+package app
+
+import "time"
+
+const keyStatsFile = "keystats.json"
+
+// keyStat is the recorded performance of a single key or bigram.
+type keyStat struct {
+	Attempts     int           `json:"attempts"`
+	Errors       int           `json:"errors"`
+	TotalLatency time.Duration `json:"totalLatency"` // summed latencies of correct keypresses
+	Timed        int           `json:"timed"`        // number of latencies summed in TotalLatency
+}
+
+// errorRate returns the smoothed error rate of the key, so that keys
+// with only a handful of attempts are not over- or under-estimated.
+func (k *keyStat) errorRate() float64 {
+	return float64(k.Errors+1) / float64(k.Attempts+2)
+}
+
+// meanLatency returns the average latency of the key.
+func (k *keyStat) meanLatency() time.Duration {
+	if k.Timed == 0 {
+		return 0
+	}
+	return k.TotalLatency / time.Duration(k.Timed)
+}
+
+// keyStats keeps track of per-key and per-bigram performance across runs.
+type keyStats struct {
+	Keys    map[string]*keyStat `json:"keys"`
+	Bigrams map[string]*keyStat `json:"bigrams"`
+}
+
+// newKeyStats returns a new empty instance of keyStats.
+func newKeyStats() *keyStats {
+	return &keyStats{
+		Keys:    map[string]*keyStat{},
+		Bigrams: map[string]*keyStat{},
+	}
+}
+
+// loadKeyStats reads the recorded key statistics from disk.
+func loadKeyStats() (*keyStats, error) {
+	k := newKeyStats()
+	if err := readJSON(keyStatsFile, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// save writes the key statistics to disk.
+func (k *keyStats) save() error {
+	return writeJSON(keyStatsFile, k)
+}
+
+// record records a keypress of the expected letter. 'previous' is the
+// letter typed right before it, or an empty string if there was none,
+// in which case neither the bigram nor the latency is recorded.
+func (k *keyStats) record(previous string, expected string, correct bool, latency time.Duration) {
+	update := func(stats map[string]*keyStat, key string) {
+		stat, ok := stats[key]
+		if !ok {
+			stat = &keyStat{}
+			stats[key] = stat
+		}
+		stat.Attempts++
+		if !correct {
+			stat.Errors++
+		} else if previous != "" {
+			stat.TotalLatency += latency
+			stat.Timed++
+		}
+	}
+
+	update(k.Keys, expected)
+	if previous != "" {
+		update(k.Bigrams, previous+expected)
+	}
+}
+
+// average returns the overall error rate and latency of the given stats.
+func average(stats map[string]*keyStat) (float64, time.Duration) {
+	total := keyStat{}
+	for _, stat := range stats {
+		total.Attempts += stat.Attempts
+		total.Errors += stat.Errors
+		total.TotalLatency += stat.TotalLatency
+		total.Timed += stat.Timed
+	}
+	return total.errorRate(), total.meanLatency()
+}
+
+// weaknesses returns how much each recorded key and bigram needs
+// practising, relative to the average of its kind. An average key
+// scores 1, anything above is weaker than average.
+func (k *keyStats) weaknesses() map[string]float64 {
+	result := map[string]float64{}
+	for _, stats := range []map[string]*keyStat{k.Keys, k.Bigrams} {
+		avgErrorRate, avgLatency := average(stats)
+		for key, stat := range stats {
+			latencyRatio := 1.0
+			if avgLatency > 0 && stat.Timed > 0 {
+				latencyRatio = float64(stat.meanLatency()) / float64(avgLatency)
+			}
+			result[key] = (stat.errorRate()/avgErrorRate + latencyRatio) / 2
+		}
+	}
+	return result
+}
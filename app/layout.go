@@ -0,0 +1,110 @@
+package app
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed layouts/*.txt
+var layoutFiles embed.FS
+
+// layoutRows is the number of rows of keys in layout files: the number
+// row, top, home and bottom rows, unshifted then shifted.
+const layoutRows int = 8
+
+// KeyboardLayout is a keyboard layout emulated on top of a QWERTY one:
+// keys are typed as if the keyboard had that layout, whatever the
+// letters printed on them.
+type KeyboardLayout struct {
+	name string
+	keys map[rune]rune // letter typed by each QWERTY key
+}
+
+func (l *KeyboardLayout) String() string { return l.name }
+
+// translate returns the letter typed by the given QWERTY key.
+func (l *KeyboardLayout) translate(r rune) rune {
+	if translated, ok := l.keys[r]; ok {
+		return translated
+	}
+	return r
+}
+
+// translateString returns the letters typed by the given QWERTY keys,
+// the same keys if l is nil.
+func (l *KeyboardLayout) translateString(keys string) string {
+	if l == nil {
+		return keys
+	}
+	return strings.Map(l.translate, keys)
+}
+
+// Layout is the keyboard layout emulated, or nil for none.
+var Layout *KeyboardLayout
+
+// layoutName returns the name of the keyboard layout emulated, empty
+// for none.
+func layoutName() string {
+	if Layout == nil {
+		return ""
+	}
+	return Layout.name
+}
+
+// LayoutNames returns the names of the available keyboard layouts.
+func LayoutNames() []string {
+	entries, _ := layoutFiles.ReadDir("layouts")
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".txt"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readLayoutRows reads the rows of keys of the named layout file.
+func readLayoutRows(name string) ([][]rune, error) {
+	data, err := layoutFiles.ReadFile(path.Join("layouts", name+".txt"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown keyboard layout %q, must be one of: %s", name, strings.Join(LayoutNames(), ", "))
+	}
+
+	rows := [][]rune{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		rows = append(rows, []rune(line))
+	}
+	if len(rows) != layoutRows {
+		return nil, fmt.Errorf("invalid keyboard layout %s: %d rows instead of %d", name, len(rows), layoutRows)
+	}
+	return rows, nil
+}
+
+// ParseLayout returns the keyboard layout of the given name.
+func ParseLayout(name string) (*KeyboardLayout, error) {
+	qwerty, err := readLayoutRows("qwerty")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readLayoutRows(name)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &KeyboardLayout{name: name, keys: map[rune]rune{}}
+	for i, row := range rows {
+		if len(row) != len(qwerty[i]) {
+			return nil, fmt.Errorf("invalid keyboard layout %s: row %d has %d keys instead of %d", name, i+1, len(row), len(qwerty[i]))
+		}
+		for j, key := range qwerty[i] {
+			l.keys[key] = row[j]
+		}
+	}
+	return l, nil
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const alphabet = "abcdefghijklmnopqrstuvwxyz"
+
+// untaught returns the characters of s that aren't in taught.
+func untaught(s string, taught string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(taught, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func TestParseLayout(t *testing.T) {
+	if names := strings.Join(LayoutNames(), " "); names != "colemak dvorak qwerty workman" {
+		t.Errorf("got layouts %q", names)
+	}
+
+	for _, name := range LayoutNames() {
+		l, err := ParseLayout(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.String() != name {
+			t.Errorf("got name %q, want %q", l, name)
+		}
+
+		// every letter is typed by a single key, in both cases
+		typed := map[rune]int{}
+		for _, r := range l.keys {
+			typed[r]++
+		}
+		for _, r := range alphabet + strings.ToUpper(alphabet) {
+			if typed[r] != 1 {
+				t.Errorf("%s: %q typed by %d keys", name, r, typed[r])
+			}
+		}
+	}
+
+	tests := []struct {
+		layout string
+		keys   string
+		want   string
+	}{
+		{"qwerty", "asdf;ZXCV<>", "asdf;ZXCV<>"},
+		{"colemak", "asdfghjkl;", "arstdhneio"},
+		{"colemak", "QWERTYUIOP", "QWFPGJLUY:"},
+		{"dvorak", "qwertyuiop", "',.pyfgcrl"},
+		{"dvorak", "zxcvbnm,./", ";qjkxbmwvz"},
+		{"dvorak", "-=_+", "[]{}"},
+		{"workman", "asdfghjkl;", "ashtgyneoi"},
+		{"workman", "hello world 123", "yroop dpwoh 123"},
+	}
+	for _, tt := range tests {
+		l, err := ParseLayout(tt.layout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := l.translateString(tt.keys); got != tt.want {
+			t.Errorf("%s: got %q typing %q, want %q", tt.layout, got, tt.keys, tt.want)
+		}
+	}
+
+	var none *KeyboardLayout
+	if got := none.translateString("asdf;"); got != "asdf;" {
+		t.Errorf("got %q without a layout", got)
+	}
+	if _, err := ParseLayout("azerty"); err == nil || !strings.Contains(err.Error(), "colemak, dvorak, qwerty, workman") {
+		t.Errorf("got error %v for an unknown layout", err)
+	}
+}
+
+func TestLessonsUnderLayouts(t *testing.T) {
+	defer func(l *KeyboardLayout) { Layout = l }(Layout)
+	defer func(f TextFilter) { Filter = f }(Filter)
+	Filter = TextFilter{}
+
+	for _, name := range append([]string{""}, LayoutNames()...) {
+		Layout = nil
+		if name != "" {
+			var err error
+			if Layout, err = ParseLayout(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the letter rows teach every letter of the layout
+		taught := ""
+		for i, lesson := range curriculum {
+			taught += Layout.translateString(lesson.keys)
+			if lesson.numbers || lesson.symbols || lesson.capitals {
+				continue
+			}
+
+			// and their drills are typed with the keys taught so far
+			q, err := (&lessonSource{index: i, words: practiceWords()}).next(withTextRand(context.Background(), 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if extra := untaught(q.Text, taught+" "); extra != "" {
+				t.Errorf("%s, %s: got %q in the drill, beyond the keys taught %q", name, lesson.name, extra, taught)
+			}
+		}
+		if missing := untaught(alphabet, taught); missing != "" {
+			t.Errorf("%s: letters %q never taught", name, missing)
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os/user"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"typechan/engine"
+)
+
+const leaderboardFile = "leaderboard.json"
+
+// leaderboardDurations are the durations of timed tests that can be
+// submitted to a leaderboard, each one having a board.
+var leaderboardDurations = []time.Duration{15 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute}
+
+var (
+	// SubmitURL is the URL of the leaderboard server results are
+	// submitted to once completed, if any.
+	SubmitURL string
+	// SubmitName is the name results are submitted under, the user's
+	// login name by default.
+	SubmitName string
+)
+
+// submission is a result submitted to a leaderboard server.
+type submission struct {
+	Name   string `json:"name"`
+	Result Result `json:"result"`
+}
+
+// LeaderboardEntry is a score on a leaderboard.
+type LeaderboardEntry struct {
+	Name   string `json:"name"`
+	Result Result `json:"result"`
+}
+
+// LeaderboardBoard lists the top scores of tests taken on the same
+// terms: in Sprint mode on the same text, in Timed mode for the same
+// duration.
+type LeaderboardBoard struct {
+	Mode     string             `json:"mode"`
+	Duration time.Duration      `json:"duration,omitempty"` // in Timed mode
+	Text     *TextInfo          `json:"text,omitempty"`     // in Sprint mode
+	Entries  []LeaderboardEntry `json:"entries"`
+}
+
+// title returns the title of the board, e.g. "Timed, 30s".
+func (b LeaderboardBoard) title() string {
+	if b.Text != nil {
+		title := "Sprint, text " + b.Text.ID
+		if attribution := b.Text.attribution(); attribution != "" {
+			title += " " + attribution
+		}
+		return title
+	}
+	return fmt.Sprintf("Timed, %v", b.Duration)
+}
+
+// LeaderboardQuery selects the boards to list. Empty fields select all.
+type LeaderboardQuery struct {
+	Mode     string
+	Duration time.Duration
+	Text     string // ID of the text on the leaderboard
+}
+
+// matches tells if the board is selected by the query.
+func (q LeaderboardQuery) matches(b LeaderboardBoard) bool {
+	switch {
+	case q.Mode != "" && q.Mode != b.Mode:
+		return false
+	case q.Duration != 0 && q.Duration != b.Duration:
+		return false
+	case q.Text != "" && (b.Text == nil || q.Text != b.Text.ID):
+		return false
+	}
+	return true
+}
+
+// values returns the query as URL query values.
+func (q LeaderboardQuery) values() url.Values {
+	values := url.Values{}
+	if q.Mode != "" {
+		values.Set("mode", q.Mode)
+	}
+	if q.Duration != 0 {
+		values.Set("duration", q.Duration.String())
+	}
+	if q.Text != "" {
+		values.Set("text", q.Text)
+	}
+	return values
+}
+
+// parseLeaderboardQuery returns the query given by URL query values.
+func parseLeaderboardQuery(values url.Values) (LeaderboardQuery, error) {
+	q := LeaderboardQuery{Mode: values.Get("mode"), Text: values.Get("text")}
+	if duration := values.Get("duration"); duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return q, fmt.Errorf("invalid duration: %w", err)
+		}
+		q.Duration = d
+	}
+	return q, nil
+}
+
+// verifyResult recomputes the outcome and metrics of a result by
+// replaying its keystroke log on its texts, so that the numbers of the
+// client don't have to be trusted. Results whose log doesn't look typed
+// by hand are rejected.
+func verifyResult(r *Result) error {
+	// flags are the server's to set, telling rejections for doubts
+	// about the log from invalid results
+	r.Flags = nil
+
+	switch r.Mode {
+	case Sprint.String():
+		if len(r.Texts) != 1 {
+			return errors.New("a sprint must be on a single text")
+		}
+		if len(r.Texts[0].Content) < leaderboardMinLength {
+			return fmt.Errorf("text must be at least %d characters long", leaderboardMinLength)
+		}
+	case Timed.String():
+		if err := CheckLeaderboardDuration(r.Duration); err != nil {
+			return err
+		}
+		if len(r.Texts) > leaderboardMaxTexts {
+			return fmt.Errorf("more than %d texts", leaderboardMaxTexts)
+		}
+		// the texts are picked again with the seed of the test, rather
+		// than taken from the client
+		if r.Seed == 0 {
+			return errors.New("missing seed")
+		}
+		rng := rand.New(rand.NewSource(r.Seed))
+		for i := range r.Texts {
+			r.Texts[i] = leaderboardWords(rng).info()
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", r.Mode)
+	}
+
+	if len(r.Texts) == 0 {
+		return errors.New("missing texts")
+	}
+	texts := []string{}
+	for _, text := range r.Texts {
+		if text.Content == "" {
+			return fmt.Errorf("missing content of text %q", text.ID)
+		}
+		texts = append(texts, text.Content)
+	}
+
+	if len(r.KeyLog) < leaderboardMinKeys {
+		return fmt.Errorf("fewer than %d keys pressed", leaderboardMinKeys)
+	}
+	if r.Mode == Timed.String() && r.KeyLog[len(r.KeyLog)-1].Time >= r.Duration {
+		return errors.New("keys pressed after the time limit")
+	}
+	r.Flags = engine.CheckLog(r.KeyLog)
+	if len(r.Flags) > 0 {
+		return fmt.Errorf("rejected: %s", strings.Join(r.Flags, ", "))
+	}
+
+	options, err := r.options()
+	if err != nil {
+		return err
+	}
+	if err := options.Check(); err != nil {
+		return err
+	}
+
+	test, err := engine.Replay(options, texts, r.KeyLog)
+	if err != nil {
+		return err
+	}
+	if r.Mode == Sprint.String() && !test.Done() {
+		return errors.New("text is not fully typed")
+	}
+	r.Failed = test.Failed()
+	r.TotalKeysPressed, r.CorrectKeysPressed = test.KeysPressed()
+	r.UncorrectedErrors = test.Text().UncorrectedErrors()
+	r.Keystrokes = engine.Summarize(test.Keystrokes())
+
+	r.Elapsed = r.KeyLog[len(r.KeyLog)-1].Time
+	if r.Mode == Timed.String() && !r.Failed {
+		r.Elapsed = r.Duration
+	}
+	r.computeMetrics()
+	if r.GrossWPM > engine.MaxWPM {
+		return fmt.Errorf("typed faster than %.0f WPM", engine.MaxWPM)
+	}
+	return nil
+}
+
+// CheckLeaderboardDuration tells if timed tests of the given duration
+// can be submitted to a leaderboard.
+func CheckLeaderboardDuration(d time.Duration) error {
+	for _, allowed := range leaderboardDurations {
+		if d == allowed {
+			return nil
+		}
+	}
+	names := []string{}
+	for _, allowed := range leaderboardDurations {
+		names = append(names, allowed.String())
+	}
+	return fmt.Errorf("timed tests of %v can't be submitted to a leaderboard, must be one of: %s", d, strings.Join(names, ", "))
+}
+
+// leaderboardWords returns a text of words of the embedded word list
+// picked with rng, whatever the Filter: the texts of timed tests
+// submitted to a leaderboard, which the server picks again from the seed
+// of the test.
+func leaderboardWords(rng *rand.Rand) quote {
+	words := practiceWords()
+	picked := []string{}
+	for i := 0; i < practiceWordCount; i++ {
+		picked = append(picked, words[rng.Intn(len(words))])
+	}
+
+	q := quote{generated: true, Source: "Leaderboard words"}
+	q.Text, q.length = processText(strings.Join(picked, " "))
+	return q
+}
+
+// leaderboardSource serves the texts of timed tests submitted to a
+// leaderboard, see leaderboardWords.
+type leaderboardSource struct{}
+
+func (s *leaderboardSource) next(ctx context.Context) (quote, error) {
+	return leaderboardWords(textRand(ctx)), nil
+}
+
+// NewLeaderboardSource returns a TextSource that serves the texts of
+// timed tests to be submitted to a leaderboard, which the server can
+// pick again from the seed of the test alone.
+func NewLeaderboardSource() TextSource {
+	return &leaderboardSource{}
+}
+
+// boardKey identifies the board a result goes on.
+func boardKey(r Result) string {
+	if r.Mode == Timed.String() {
+		return fmt.Sprintf("%s %v", r.Mode, r.Duration)
+	}
+	return r.Mode + " " + boardTextID(r.Texts[0])
+}
+
+// boardTextID returns the ID of a text on the leaderboard, derived from
+// its content: the ID sent by the client can't be trusted to match the
+// text that was typed.
+func boardTextID(text TextInfo) string {
+	return quote{Text: text.Content}.id()
+}
+
+// leaderboardServer serves leaderboards over HTTP, keeping all the
+// entries submitted in the data directory.
+type leaderboardServer struct {
+	mu      sync.Mutex
+	entries []LeaderboardEntry
+	metrics *metricsRegistry
+}
+
+// submit handles results submitted with POST /results.
+func (s *leaderboardServer) submit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var sub submission
+	if err := json.NewDecoder(io.LimitReader(r.Body, leaderboardMaxBody)).Decode(&sub); err != nil {
+		s.metrics.resultRejected("invalid")
+		http.Error(w, "invalid submission: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub.Name = strings.TrimSpace(sub.Name)
+	if sub.Name == "" || len(sub.Name) > leaderboardMaxName {
+		s.metrics.resultRejected("invalid")
+		http.Error(w, fmt.Sprintf("name must be 1 to %d characters long", leaderboardMaxName), http.StatusBadRequest)
+		return
+	}
+	if err := verifyResult(&sub.Result); err != nil {
+		if len(sub.Result.Flags) > 0 {
+			s.metrics.resultRejected("flagged")
+		} else {
+			s.metrics.resultRejected("invalid")
+		}
+		http.Error(w, "invalid result: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.entries = append(s.entries, LeaderboardEntry{Name: sub.Name, Result: sub.Result})
+	err := writeJSON(leaderboardFile, s.entries)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.metrics.resultAccepted(sub.Result)
+
+	sub.Result.KeyLog = nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(LeaderboardEntry{Name: sub.Name, Result: sub.Result})
+}
+
+// session handles the events of the sessions of clients reported with
+// POST /sessions.
+func (s *leaderboardServer) session(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var e sessionEvent
+	if err := json.NewDecoder(io.LimitReader(r.Body, leaderboardMaxBody)).Decode(&e); err != nil {
+		http.Error(w, "invalid event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if e.Session == "" || len(e.Session) > sessionMaxID {
+		http.Error(w, fmt.Sprintf("session ID must be 1 to %d characters long", sessionMaxID), http.StatusBadRequest)
+		return
+	}
+	switch e.Event {
+	case sessionStarted:
+		s.metrics.sessionActive(e.Session)
+	case sessionEnded:
+		s.metrics.sessionEnded(e.Session)
+	case sessionFetchFailed:
+		if e.Mode != Sprint.String() && e.Mode != Timed.String() {
+			http.Error(w, fmt.Sprintf("unknown mode %q", e.Mode), http.StatusBadRequest)
+			return
+		}
+		s.metrics.sessionActive(e.Session)
+		s.metrics.fetchFailed(e.Mode)
+	default:
+		http.Error(w, fmt.Sprintf("unknown event %q", e.Event), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// boards handles the listing of boards with GET /boards, optionally
+// filtered by the mode, duration and text query parameters.
+func (s *leaderboardServer) boards(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query, err := parseLeaderboardQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	boards := rankEntries(s.entries)
+	s.mu.Unlock()
+
+	selected := []LeaderboardBoard{}
+	for _, b := range boards {
+		if query.matches(b) {
+			selected = append(selected, b)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(selected)
+}
+
+// rankEntries sorts the entries into boards of the best scores, by
+// adjusted WPM, keeping the best one of each name. Failed tests are
+// left out.
+func rankEntries(entries []LeaderboardEntry) []LeaderboardBoard {
+	boards := map[string]*LeaderboardBoard{}
+	best := map[string]map[string]int{} // index of the best entry of each name, by board
+	for _, e := range entries {
+		if e.Result.Failed {
+			continue
+		}
+		key := boardKey(e.Result)
+		board, ok := boards[key]
+		if !ok {
+			board = &LeaderboardBoard{Mode: e.Result.Mode}
+			if e.Result.Mode == Timed.String() {
+				board.Duration = e.Result.Duration
+			} else {
+				text := e.Result.Texts[0]
+				text.ID, text.Content = boardTextID(text), ""
+				board.Text = &text
+			}
+			boards[key] = board
+			best[key] = map[string]int{}
+		}
+
+		// the board describes the text already
+		e.Result.Texts, e.Result.KeyLog = nil, nil
+		if i, ok := best[key][e.Name]; !ok {
+			best[key][e.Name] = len(board.Entries)
+			board.Entries = append(board.Entries, e)
+		} else if e.Result.AdjustedWPM > board.Entries[i].Result.AdjustedWPM {
+			board.Entries[i] = e
+		}
+	}
+
+	ranked := []LeaderboardBoard{}
+	for _, key := range sortedKeys(boards) {
+		board := boards[key]
+		sort.SliceStable(board.Entries, func(i, j int) bool {
+			return board.Entries[i].Result.AdjustedWPM > board.Entries[j].Result.AdjustedWPM
+		})
+		if len(board.Entries) > leaderboardSize {
+			board.Entries = board.Entries[:leaderboardSize]
+		}
+		ranked = append(ranked, *board)
+	}
+	return ranked
+}
+
+// NewLeaderboardHandler returns the handler of a leaderboard server,
+// taking results on POST /results and listing boards on GET /boards.
+// Entries are kept in the data directory. Metrics of the results
+// submitted since the server started, and of the sessions of clients
+// reported on POST /sessions, are served on /metrics.
+func NewLeaderboardHandler() (http.Handler, error) {
+	s := &leaderboardServer{entries: []LeaderboardEntry{}, metrics: newMetricsRegistry()}
+	if err := readJSON(leaderboardFile, &s.entries); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/results", s.submit)
+	mux.HandleFunc("/boards", s.boards)
+	mux.HandleFunc("/sessions", s.session)
+	mux.Handle("/metrics", s.metrics)
+	return mux, nil
+}
+
+// leaderboardURL returns the URL of the given path on the leaderboard
+// server at base.
+func leaderboardURL(base string, path string) string {
+	return strings.TrimSuffix(base, "/") + path
+}
+
+// FetchLeaderboard fetches the boards selected by the query from the
+// leaderboard server at the given URL.
+func FetchLeaderboard(base string, query LeaderboardQuery) ([]LeaderboardBoard, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	u := leaderboardURL(base, "/boards")
+	if values := query.values(); len(values) > 0 {
+		u += "?" + values.Encode()
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("leaderboard returns code %v: %s", resp.StatusCode, resp.Status)
+	}
+	boards := []LeaderboardBoard{}
+	if err := json.NewDecoder(resp.Body).Decode(&boards); err != nil {
+		return nil, err
+	}
+	return boards, nil
+}
+
+// leaderboardSink submits results to a leaderboard server.
+type leaderboardSink struct {
+	url    string
+	name   string
+	client *http.Client
+}
+
+func (s *leaderboardSink) String() string { return "leaderboard " + s.url }
+
+func (s *leaderboardSink) send(ctx context.Context, r Result) error {
+	body, err := json.Marshal(submission{Name: s.name, Result: r})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, leaderboardURL(s.url, "/results"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		// the server tells why the result was turned down
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("leaderboard returns code %v: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// newLeaderboardSink returns a new instance of leaderboardSink,
+// submitting to SubmitURL under SubmitName.
+func newLeaderboardSink() (*leaderboardSink, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	name := SubmitName
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	if name == "" {
+		return nil, errors.New("no name to submit results under")
+	}
+	return &leaderboardSink{url: SubmitURL, name: name, client: client}, nil
+}
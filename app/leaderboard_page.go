@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// leaderboardPage is the page model listing the top scores of the
+// boards of a leaderboard, one board at a time.
+type leaderboardPage struct {
+	app    *app
+	boards []LeaderboardBoard
+	index  int // index of the board shown
+}
+
+func (l *leaderboardPage) init() error {
+	return nil
+}
+
+func (l *leaderboardPage) update(msg tea.Msg) (tea.Cmd, error) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			// exit
+			return tea.Quit, nil
+		case tea.KeyRight, tea.KeyTab:
+			l.index = (l.index + 1) % len(l.boards)
+		case tea.KeyLeft, tea.KeyShiftTab:
+			l.index = (l.index + len(l.boards) - 1) % len(l.boards)
+		}
+	}
+	return nil, nil
+}
+
+func (l *leaderboardPage) view() string {
+	board := l.boards[l.index]
+
+	str := lipgloss.NewStyle().Bold(true).Render(board.title()) + "\n"
+	str += lipgloss.NewStyle().Foreground(grey).Render(fmt.Sprintf("board %d of %d", l.index+1, len(l.boards))) + "\n\n"
+
+	nameWidth := 0
+	for _, e := range board.Entries {
+		if len(e.Name) > nameWidth {
+			nameWidth = len(e.Name)
+		}
+	}
+	for i, e := range board.Entries {
+		str += fmt.Sprintf("%2d. %-*s  %6.2f WPM  %6.2f%%  %s\n", i+1, nameWidth, e.Name,
+			e.Result.AdjustedWPM, e.Result.Accuracy*100, e.Result.Date.Format("2006-01-02"))
+	}
+
+	return lipgloss.NewStyle().PaddingLeft(paddingX).Render(str) + "\n" +
+		strings.Repeat(" ", paddingX) + lipgloss.NewStyle().Foreground(grey).Render("left/right to change board") + "\n" +
+		strings.Repeat(" ", paddingX) + lipgloss.NewStyle().Foreground(grey).Render("esc or ctrl+c to quit")
+}
+
+// newLeaderboardPage returns a new instance of leaderboardPage.
+func newLeaderboardPage(app *app, boards []LeaderboardBoard) *leaderboardPage {
+	return &leaderboardPage{app: app, boards: boards}
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"typechan/engine"
+)
+
+// leaderboardText is a text long enough for a leaderboard.
+const leaderboardText = "the quick brown fox jumps over the lazy dog while " +
+	"pack my box with five dozen liquor jugs and sphinx of black quartz judge my vow"
+
+// humanInterval returns the interval before the ith key of a log, uneven
+// and around 200ms as a person would type.
+func humanInterval(i int) time.Duration {
+	return time.Duration(150+(i*37)%110) * time.Millisecond
+}
+
+// typedLog returns the keystroke log of the text typed without mistakes,
+// with the given interval before each key.
+func typedLog(text string, interval func(i int) time.Duration) []engine.LoggedKey {
+	log := []engine.LoggedKey{}
+	var t time.Duration
+	for i, r := range text {
+		key := engine.Key{Kind: engine.LetterKey, Letter: string(r)}
+		switch r {
+		case ' ':
+			key = engine.Key{Kind: engine.SpaceKey}
+		case '\n':
+			key = engine.Key{Kind: engine.EnterKey}
+		}
+		if i > 0 {
+			t += interval(i)
+		}
+		log = append(log, engine.LoggedKey{Time: t, Key: key})
+	}
+	return log
+}
+
+// sprintResult returns a sprint result on the given text, as claimed by
+// the client.
+func sprintResult(id, content string) Result {
+	return Result{
+		Mode:        Sprint.String(),
+		ErrorPolicy: engine.Lenient.String(),
+		Texts:       []TextInfo{{ID: id, Content: content}},
+		KeyLog:      typedLog(content, humanInterval),
+		Metrics:     engine.Metrics{AdjustedWPM: 999},
+	}
+}
+
+// timedResult returns the result of a timed test of the given duration
+// and seed, on the first texts of the seed up to the given number of
+// letters typed, as claimed by a client which sent other texts.
+func timedResult(duration time.Duration, seed int64, letters int) Result {
+	rng := rand.New(rand.NewSource(seed))
+	text := ""
+	texts := []TextInfo{}
+	for len(text) < letters {
+		if text != "" {
+			text += "\n"
+		}
+		text += leaderboardWords(rng).Text
+		texts = append(texts, TextInfo{Content: "a a a a"})
+	}
+	return Result{
+		Mode:        Timed.String(),
+		ErrorPolicy: engine.Lenient.String(),
+		Duration:    duration,
+		Seed:        seed,
+		Texts:       texts,
+		KeyLog:      typedLog(text[:letters], humanInterval),
+	}
+}
+
+// submitResult submits the result under the name to the leaderboard
+// handler, returning the response code.
+func submitResult(t *testing.T, handler http.Handler, name string, r Result) int {
+	t.Helper()
+	body, err := json.Marshal(submission{Name: name, Result: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/results", bytes.NewReader(body)))
+	return w.Code
+}
+
+// listBoards returns the boards listed by the leaderboard handler.
+func listBoards(t *testing.T, handler http.Handler, query LeaderboardQuery) []LeaderboardBoard {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boards?"+query.values().Encode(), nil))
+	boards := []LeaderboardBoard{}
+	if err := json.NewDecoder(w.Body).Decode(&boards); err != nil {
+		t.Fatal(err)
+	}
+	return boards
+}
+
+func TestVerifyResult(t *testing.T) {
+	r := sprintResult("q1", leaderboardText)
+	if err := verifyResult(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.AdjustedWPM >= 999 || r.TotalKeysPressed != len(leaderboardText) || r.Elapsed != r.KeyLog[len(r.KeyLog)-1].Time {
+		t.Errorf("got metrics %+v, keys %d, elapsed %v not recomputed", r.Metrics, r.TotalKeysPressed, r.Elapsed)
+	}
+
+	// the texts of a timed test are picked again from its seed
+	r = timedResult(time.Minute, 42, 200)
+	if err := verifyResult(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Elapsed != time.Minute {
+		t.Errorf("got %v elapsed in a timed test of 1m", r.Elapsed)
+	}
+	if len(r.Texts) != 2 || r.Texts[0].Content != leaderboardWords(rand.New(rand.NewSource(42))).Text || r.CorrectKeysPressed != 200 {
+		t.Errorf("got texts %+v, %d correct keys", r.Texts, r.CorrectKeysPressed)
+	}
+
+	tests := []struct {
+		want   string // error
+		result Result
+	}{
+		{"text is not fully typed", func() Result {
+			r := sprintResult("q1", leaderboardText)
+			r.KeyLog = r.KeyLog[:60]
+			return r
+		}()},
+		{"text must be at least 100 characters long", sprintResult("q1", leaderboardText[:99])},
+		{"fewer than 50 keys pressed", func() Result {
+			r := sprintResult("q1", strings.Repeat("a ", 50)+"a")
+			r.SkipAhead = true
+			r.KeyLog = r.KeyLog[:1]
+			for i := 0; i < 48; i++ {
+				r.KeyLog = append(r.KeyLog, engine.LoggedKey{Time: r.KeyLog[i].Time + humanInterval(i), Key: engine.Key{Kind: engine.SpaceKey}})
+			}
+			return r
+		}()},
+		{"typed faster than 350 WPM", func() Result {
+			// too few keys for CheckLog to tell
+			r := sprintResult("q1", leaderboardText[:100])
+			r.KeyLog = typedLog(leaderboardText[:100], func(i int) time.Duration {
+				return time.Duration(20+i%2*20) * time.Millisecond
+			})
+			return r
+		}()},
+		{"keys pressed after the time limit", timedResult(15*time.Second, 42, 100)},
+		{"timed tests of 1ms can't be submitted", timedResult(time.Millisecond, 42, 1)},
+		{"fewer than 50 keys pressed", timedResult(15*time.Second, 42, 1)},
+		{"missing seed", timedResult(time.Minute, 0, 100)},
+		{"fewer than 50 keys pressed", func() Result {
+			r := sprintResult("q1", leaderboardText)
+			r.KeyLog = nil
+			return r
+		}()},
+		{"unknown mode", func() Result {
+			r := sprintResult("q1", leaderboardText)
+			r.Mode = "marathon"
+			return r
+		}()},
+	}
+	for _, tt := range tests {
+		if err := verifyResult(&tt.result); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got error %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestLeaderboardBoardsByContent(t *testing.T) {
+	DataDir = t.TempDir()
+	handler, err := NewLeaderboardHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const text = leaderboardText
+	easy := strings.Repeat("a ", 60) + "a"
+	submissions := []struct {
+		name   string
+		result Result
+	}{
+		{"alice", sprintResult("q1", text)},
+		{"bob", sprintResult("other", text)}, // the same text, whatever its ID
+		{"eve", sprintResult("q1", easy)},    // another text claiming the ID of the first
+	}
+	for _, s := range submissions {
+		if code := submitResult(t, handler, s.name, s.result); code != http.StatusCreated {
+			t.Fatalf("%s: got code %d", s.name, code)
+		}
+	}
+
+	boards := listBoards(t, handler, LeaderboardQuery{})
+	if len(boards) != 2 {
+		t.Fatalf("got %d boards, want 2", len(boards))
+	}
+	names := map[string][]string{}
+	for _, b := range boards {
+		for _, e := range b.Entries {
+			names[b.Text.ID] = append(names[b.Text.ID], e.Name)
+		}
+	}
+	textID := boardTextID(TextInfo{Content: text})
+	if got := strings.Join(names[textID], " "); got != "alice bob" && got != "bob alice" {
+		t.Errorf("got %q on the board of the text, want alice and bob", got)
+	}
+	if got := strings.Join(names[boardTextID(TextInfo{Content: easy})], " "); got != "eve" {
+		t.Errorf("got %q on the board of the other text, want eve", got)
+	}
+
+	boards = listBoards(t, handler, LeaderboardQuery{Text: textID})
+	if len(boards) != 1 || len(boards[0].Entries) != 2 {
+		t.Errorf("got boards %+v selecting the text by its ID", boards)
+	}
+	if boards := listBoards(t, handler, LeaderboardQuery{Text: "q1"}); len(boards) != 0 {
+		t.Errorf("got boards %+v selected by an ID sent by a client", boards)
+	}
+}
+
+func TestLeaderboardRejects(t *testing.T) {
+	DataDir = t.TempDir()
+	handler, err := NewLeaderboardHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	late := timedResult(15*time.Second, 42, 100)
+	if code := submitResult(t, handler, "alice", late); code != http.StatusBadRequest {
+		t.Errorf("got code %d for keys pressed after the time limit", code)
+	}
+	if code := submitResult(t, handler, "", sprintResult("q1", leaderboardText)); code != http.StatusBadRequest {
+		t.Errorf("got code %d without a name", code)
+	}
+	if boards := listBoards(t, handler, LeaderboardQuery{}); len(boards) != 0 {
+		t.Errorf("got boards %+v of rejected results", boards)
+	}
+}
+
+func TestLeaderboardRejectionReasons(t *testing.T) {
+	DataDir = t.TempDir()
+	handler, err := NewLeaderboardHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flags sent by the client don't make a rejection flagged
+	bogus := sprintResult("q1", leaderboardText)
+	bogus.Mode, bogus.Flags = "bogus", []string{"x"}
+	pasted := sprintResult("q1", leaderboardText)
+	pasted.KeyLog = typedLog(leaderboardText, func(i int) time.Duration { return time.Millisecond })
+	for _, r := range []Result{bogus, pasted} {
+		if code := submitResult(t, handler, "eve", r); code != http.StatusBadRequest {
+			t.Errorf("got code %d", code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`typechan_results_rejected_total{reason="flagged"} 1`,
+		`typechan_results_rejected_total{reason="invalid"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want+"\n") {
+			t.Errorf("got metrics without %s:\n%s", want, w.Body)
+		}
+	}
+}
+
+func TestLeaderboardSessions(t *testing.T) {
+	DataDir = t.TempDir()
+	defer func(url string) { SubmitURL = url }(SubmitURL)
+	handler, err := NewLeaderboardHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	SubmitURL = server.URL
+
+	metrics := func() string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+
+	// events are reported in order, all of them by the end of the session
+	alice, err := newSessionReporter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := newSessionReporter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice.report(sessionStarted, Timed)
+	alice.report(sessionFetchFailed, Timed)
+	bob.report(sessionFetchFailed, Sprint)
+	alice.end()
+	for _, want := range []string{
+		"typechan_active_sessions 1",
+		`typechan_text_fetch_errors_total{mode="sprint"} 1`,
+		`typechan_text_fetch_errors_total{mode="timed"} 1`,
+	} {
+		if !strings.Contains(metrics(), want+"\n") {
+			t.Errorf("got metrics without %s:\n%s", want, metrics())
+		}
+	}
+	bob.end()
+	if !strings.Contains(metrics(), "typechan_active_sessions 0\n") {
+		t.Errorf("got sessions still active once ended:\n%s", metrics())
+	}
+
+	var none *sessionReporter
+	none.report(sessionStarted, Sprint)
+	none.end()
+
+	for _, e := range []sessionEvent{
+		{Session: "", Event: sessionStarted},
+		{Session: strings.Repeat("a", 65), Event: sessionStarted},
+		{Session: "a", Event: "restart"},
+		{Session: "a", Event: sessionFetchFailed, Mode: "marathon"},
+	} {
+		body, _ := json.Marshal(e)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/sessions", bytes.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got code %d for event %+v", w.Code, e)
+		}
+	}
+}
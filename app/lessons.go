@@ -0,0 +1,229 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+const lessonProgressFile = "lessons.json"
+
+// lesson is a single step of the learning curriculum. Each lesson
+// builds upon the keys introduced by the lessons before it.
+type lesson struct {
+	id          string
+	name        string
+	keys        string // keys introduced by this lesson, by their characters on a QWERTY keyboard
+	numbers     bool
+	symbols     bool
+	capitals    bool
+	minWPM      float64 // adjusted WPM required to unlock the next lesson
+	minAccuracy float64 // accuracy (0 to 1) required to unlock the next lesson
+}
+
+// curriculum lists the lessons in the order they are to be learnt.
+var curriculum = []lesson{
+	// rows of letter keys, whole, since other layouts put letters on the
+	// keys of QWERTY punctuation
+	{id: "home-row", name: "Home row", keys: "asdfghjkl;", minWPM: 15, minAccuracy: 0.90},
+	{id: "top-row", name: "Top row", keys: "qwertyuiop", minWPM: 20, minAccuracy: 0.92},
+	{id: "bottom-row", name: "Bottom row", keys: "zxcvbnm,./", minWPM: 25, minAccuracy: 0.93},
+	{id: "numbers", name: "Numbers", numbers: true, minWPM: 25, minAccuracy: 0.94},
+	{id: "symbols", name: "Symbols", symbols: true, minWPM: 25, minAccuracy: 0.94},
+	{id: "capitals", name: "Capitals", capitals: true, minWPM: 30, minAccuracy: 0.95},
+}
+
+// lessonBest is the best result achieved in a lesson.
+type lessonBest struct {
+	WPM      float64 `json:"wpm"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// lessonProgress is the persisted progress through the curriculum.
+type lessonProgress struct {
+	Passed int                   `json:"passed"` // number of lessons passed, in order
+	Best   map[string]lessonBest `json:"best"`
+}
+
+// loadLessonProgress reads the curriculum progress from disk.
+func loadLessonProgress() (*lessonProgress, error) {
+	p := &lessonProgress{Best: map[string]lessonBest{}}
+	if err := readJSON(lessonProgressFile, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// save writes the curriculum progress to disk.
+func (p *lessonProgress) save() error {
+	return writeJSON(lessonProgressFile, p)
+}
+
+// isUnlocked tells if the lesson at the given index can be taken.
+func (p *lessonProgress) isUnlocked(index int) bool {
+	return index <= p.Passed
+}
+
+// currentLesson returns the index of the furthest unlocked lesson.
+func (p *lessonProgress) currentLesson() int {
+	if p.Passed >= len(curriculum) {
+		return len(curriculum) - 1
+	}
+	return p.Passed
+}
+
+// lessonSource generates drills for a lesson of the curriculum, and
+// unlocks the next lesson once the criteria are met.
+type lessonSource struct {
+	index int
+	words []string
+}
+
+func (s *lessonSource) next(ctx context.Context) (quote, error) {
+	// letters, numbers, symbols and capitals taught so far
+	letters := ""
+	var numbers, symbols, capitals bool
+	for _, prev := range curriculum[:s.index+1] {
+		letters += Layout.translateString(prev.keys)
+		numbers = numbers || prev.numbers
+		symbols = symbols || prev.symbols
+		capitals = capitals || prev.capitals
+	}
+
+	// real words that can be typed with the letters taught so far
+	pool := []string{}
+	for _, word := range s.words {
+		if strings.Trim(word, letters) == "" {
+			pool = append(pool, word)
+		}
+	}
+
+	rng := textRand(ctx)
+	q := generateText(lessonWordCount, func() string {
+		var token string
+		switch {
+		case numbers && rng.Float64() < 0.3:
+			token = randomGroup(rng, "0123456789")
+		case len(pool) >= lessonMinPoolSize && rng.Float64() < 0.7:
+			token = pool[rng.Intn(len(pool))]
+		default:
+			token = randomGroup(rng, letters)
+		}
+
+		if symbols && rng.Float64() < 0.3 {
+			token = lessonSymbols[rng.Intn(len(lessonSymbols))](token)
+		}
+		if capitals && rng.Float64() < 0.4 {
+			token = strings.ToUpper(token[:1]) + token[1:]
+		}
+		return token
+	})
+	q.Source = fmt.Sprintf("Lesson %d: %s", s.index+1, curriculum[s.index].name)
+	return q, nil
+}
+
+// randomGroup returns a random group of 2 to 5 characters taken from the given set.
+func randomGroup(rng *rand.Rand, set string) string {
+	group := make([]byte, 2+rng.Intn(4))
+	for i := range group {
+		group[i] = set[rng.Intn(len(set))]
+	}
+	return string(group)
+}
+
+// onResult checks the result against the lesson's unlock criteria, and
+// moves on to the next lesson if they are met.
+func (s *lessonSource) onResult(wpm float64, accuracy float64) (string, error) {
+	progress, err := loadLessonProgress()
+	if err != nil {
+		return "", err
+	}
+
+	l := curriculum[s.index]
+	if best := progress.Best[l.id]; wpm > best.WPM {
+		progress.Best[l.id] = lessonBest{WPM: wpm, Accuracy: accuracy}
+	}
+
+	var message string
+	if wpm < l.minWPM || accuracy < l.minAccuracy {
+		message = fmt.Sprintf("Reach %.0f WPM with %.0f%% accuracy to pass the lesson.", l.minWPM, l.minAccuracy*100)
+	} else {
+		if s.index == progress.Passed {
+			progress.Passed++
+		}
+		if s.index+1 < len(curriculum) {
+			s.index++
+			message = fmt.Sprintf("Lesson passed! Up next: %s.", curriculum[s.index].name)
+		} else {
+			message = "Lesson passed! You have completed the curriculum."
+		}
+	}
+
+	if err := progress.save(); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// lessonSymbols decorate a word with punctuation.
+var lessonSymbols = []func(string) string{
+	func(w string) string { return w + "," },
+	func(w string) string { return w + "." },
+	func(w string) string { return w + ";" },
+	func(w string) string { return w + ":" },
+	func(w string) string { return w + "!" },
+	func(w string) string { return w + "?" },
+	func(w string) string { return w + "'s" },
+	func(w string) string { return "(" + w + ")" },
+	func(w string) string { return "\"" + w + "\"" },
+	func(w string) string { return w + "-" + w },
+}
+
+// NewLessonSource returns a TextSource for the given lesson number of
+// the curriculum, counted from 1. A number of 0 picks the furthest
+// unlocked lesson.
+func NewLessonSource(number int) (TextSource, error) {
+	progress, err := loadLessonProgress()
+	if err != nil {
+		return nil, err
+	}
+
+	index := number - 1
+	if number == 0 {
+		index = progress.currentLesson()
+	}
+	if index < 0 || index >= len(curriculum) {
+		return nil, fmt.Errorf("lesson must be between 1 and %d", len(curriculum))
+	}
+	if !progress.isUnlocked(index) {
+		return nil, fmt.Errorf("lesson %d is locked, pass lesson %d first", number, progress.currentLesson()+1)
+	}
+
+	return &lessonSource{
+		index: index,
+		words: practiceWords(),
+	}, nil
+}
+
+// ListLessons writes the curriculum along with the user's progress to w.
+func ListLessons(w io.Writer) error {
+	progress, err := loadLessonProgress()
+	if err != nil {
+		return err
+	}
+
+	for i, l := range curriculum {
+		status := "locked"
+		if progress.isUnlocked(i) {
+			status = "unlocked"
+		}
+		if best, ok := progress.Best[l.id]; ok {
+			status += fmt.Sprintf(", best %.1f WPM at %.1f%%", best.WPM, best.Accuracy*100)
+		}
+		fmt.Fprintf(w, "%d. %-12s %3.0f WPM, %.0f%% accuracy to pass (%s)\n",
+			i+1, l.name, l.minWPM, l.minAccuracy*100, status)
+	}
+	return nil
+}
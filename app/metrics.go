@@ -0,0 +1,185 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wpmBuckets and accuracyBuckets are the upper bounds of the buckets of
+// the WPM and accuracy histograms.
+var (
+	wpmBuckets      = []float64{20, 40, 60, 80, 100, 120, 150}
+	accuracyBuckets = []float64{0.5, 0.8, 0.9, 0.95, 0.98, 0.99, 1}
+)
+
+// histogram counts observations into buckets of upper bounds, the way
+// Prometheus histograms do.
+type histogram struct {
+	bounds []float64
+	counts []int // cumulative, by bound
+	sum    float64
+	count  int
+}
+
+// observe adds the value to the histogram.
+func (h *histogram) observe(value float64) {
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// newHistogram returns a new instance of histogram.
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]int, len(bounds))}
+}
+
+// metricsRegistry keeps track of the results submitted to a leaderboard
+// server and of the sessions of its clients, to be scraped by Prometheus.
+type metricsRegistry struct {
+	mu          sync.Mutex
+	accepted    map[string]int        // by mode
+	rejected    map[string]int        // by reason, see rejectionReason
+	wpm         map[string]*histogram // of accepted results, by mode
+	accuracy    map[string]*histogram // of accepted results, by mode
+	sessions    map[string]time.Time  // active, by ID: when last heard of
+	fetchErrors map[string]int        // by mode
+	clock       Clock
+}
+
+// newMetricsRegistry returns a new instance of metricsRegistry.
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{
+		accepted:    map[string]int{},
+		rejected:    map[string]int{},
+		wpm:         map[string]*histogram{},
+		accuracy:    map[string]*histogram{},
+		sessions:    map[string]time.Time{},
+		fetchErrors: map[string]int{},
+		clock:       systemClock{},
+	}
+}
+
+// resultAccepted records a result accepted onto the leaderboard.
+func (m *metricsRegistry) resultAccepted(r Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accepted[r.Mode]++
+	if _, ok := m.wpm[r.Mode]; !ok {
+		m.wpm[r.Mode] = newHistogram(wpmBuckets)
+		m.accuracy[r.Mode] = newHistogram(accuracyBuckets)
+	}
+	m.wpm[r.Mode].observe(r.AdjustedWPM)
+	m.accuracy[r.Mode].observe(r.Accuracy)
+}
+
+// resultRejected records a submission turned down for the given reason.
+func (m *metricsRegistry) resultRejected(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejected[reason]++
+}
+
+// sessionActive records the session of the given ID as active, until it
+// ends or isn't heard of for sessionTimeout.
+func (m *metricsRegistry) sessionActive(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[id] = m.clock.Now()
+}
+
+// sessionEnded records the end of the session of the given ID.
+func (m *metricsRegistry) sessionEnded(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+// fetchFailed records a text a client failed to fetch in the given mode.
+func (m *metricsRegistry) fetchFailed(mode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchErrors[mode]++
+}
+
+// write writes the metrics in the Prometheus text format.
+func (m *metricsRegistry) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// clients may quit without telling, e.g. when killed
+	for id, seen := range m.sessions {
+		if m.clock.Now().Sub(seen) > sessionTimeout {
+			delete(m.sessions, id)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "# HELP typechan_results_accepted_total Number of results accepted onto the leaderboard, failed ones included.")
+	fmt.Fprintln(&b, "# TYPE typechan_results_accepted_total counter")
+	for _, mode := range sortedKeys(m.accepted) {
+		fmt.Fprintf(&b, "typechan_results_accepted_total{mode=%q} %d\n", mode, m.accepted[mode])
+	}
+
+	fmt.Fprintln(&b, "# HELP typechan_results_rejected_total Number of submissions turned down, by reason: invalid or flagged.")
+	fmt.Fprintln(&b, "# TYPE typechan_results_rejected_total counter")
+	for _, reason := range sortedKeys(m.rejected) {
+		fmt.Fprintf(&b, "typechan_results_rejected_total{reason=%q} %d\n", reason, m.rejected[reason])
+	}
+
+	writeHistograms(&b, "typechan_result_wpm", "Adjusted WPM of the results accepted.", m.wpm)
+	writeHistograms(&b, "typechan_result_accuracy", "Accuracy of the results accepted, range 0 to 1.", m.accuracy)
+
+	fmt.Fprintf(&b, "# HELP typechan_active_sessions Number of clients submitting to the leaderboard running, heard of in the last %v.\n", sessionTimeout)
+	fmt.Fprintln(&b, "# TYPE typechan_active_sessions gauge")
+	fmt.Fprintf(&b, "typechan_active_sessions %d\n", len(m.sessions))
+
+	fmt.Fprintln(&b, "# HELP typechan_text_fetch_errors_total Number of texts clients failed to fetch from their source, by mode.")
+	fmt.Fprintln(&b, "# TYPE typechan_text_fetch_errors_total counter")
+	for _, mode := range sortedKeys(m.fetchErrors) {
+		fmt.Fprintf(&b, "typechan_text_fetch_errors_total{mode=%q} %d\n", mode, m.fetchErrors[mode])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHistograms writes histograms by mode in the Prometheus text format.
+func writeHistograms(b *strings.Builder, name string, help string, histograms map[string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	for _, mode := range sortedKeys(histograms) {
+		h := histograms[mode]
+		for i, bound := range h.bounds {
+			fmt.Fprintf(b, "%s_bucket{mode=%q,le=%q} %d\n", name, mode, strconv.FormatFloat(bound, 'f', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{mode=%q,le=\"+Inf\"} %d\n", name, mode, h.count)
+		fmt.Fprintf(b, "%s_sum{mode=%q} %s\n", name, mode, strconv.FormatFloat(h.sum, 'f', -1, 64))
+		fmt.Fprintf(b, "%s_count{mode=%q} %d\n", name, mode, h.count)
+	}
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
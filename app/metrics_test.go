@@ -0,0 +1,127 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"typechan/engine"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMetricsOutput(t *testing.T) {
+	m := newMetricsRegistry()
+	m.resultAccepted(Result{Mode: "sprint", Metrics: engine.Metrics{AdjustedWPM: 45.5, Accuracy: 0.97}})
+	m.resultAccepted(Result{Mode: "sprint", Metrics: engine.Metrics{AdjustedWPM: 130, Accuracy: 1}})
+	m.resultAccepted(Result{Mode: "timed", Metrics: engine.Metrics{AdjustedWPM: 200, Accuracy: 0.4}})
+	m.resultRejected("flagged")
+	m.resultRejected("invalid")
+	m.resultRejected("invalid")
+
+	clock := NewManualClock(time.Date(2023, 3, 4, 12, 0, 0, 0, time.UTC))
+	m.clock = clock
+	for _, id := range []string{"a", "b", "c", "d"} {
+		m.sessionActive(id)
+	}
+	m.sessionEnded("b")
+	clock.Advance(sessionTimeout, func(tea.Msg) error { return nil })
+	m.sessionActive("c")
+	clock.Advance(time.Second, func(tea.Msg) error { return nil })
+	m.fetchFailed("timed")
+	m.fetchFailed("timed")
+	m.fetchFailed("sprint")
+
+	server := httptest.NewServer(m)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %q", got)
+	}
+	want := `# HELP typechan_results_accepted_total Number of results accepted onto the leaderboard, failed ones included.
+# TYPE typechan_results_accepted_total counter
+typechan_results_accepted_total{mode="sprint"} 2
+typechan_results_accepted_total{mode="timed"} 1
+# HELP typechan_results_rejected_total Number of submissions turned down, by reason: invalid or flagged.
+# TYPE typechan_results_rejected_total counter
+typechan_results_rejected_total{reason="flagged"} 1
+typechan_results_rejected_total{reason="invalid"} 2
+# HELP typechan_result_wpm Adjusted WPM of the results accepted.
+# TYPE typechan_result_wpm histogram
+typechan_result_wpm_bucket{mode="sprint",le="20"} 0
+typechan_result_wpm_bucket{mode="sprint",le="40"} 0
+typechan_result_wpm_bucket{mode="sprint",le="60"} 1
+typechan_result_wpm_bucket{mode="sprint",le="80"} 1
+typechan_result_wpm_bucket{mode="sprint",le="100"} 1
+typechan_result_wpm_bucket{mode="sprint",le="120"} 1
+typechan_result_wpm_bucket{mode="sprint",le="150"} 2
+typechan_result_wpm_bucket{mode="sprint",le="+Inf"} 2
+typechan_result_wpm_sum{mode="sprint"} 175.5
+typechan_result_wpm_count{mode="sprint"} 2
+typechan_result_wpm_bucket{mode="timed",le="20"} 0
+typechan_result_wpm_bucket{mode="timed",le="40"} 0
+typechan_result_wpm_bucket{mode="timed",le="60"} 0
+typechan_result_wpm_bucket{mode="timed",le="80"} 0
+typechan_result_wpm_bucket{mode="timed",le="100"} 0
+typechan_result_wpm_bucket{mode="timed",le="120"} 0
+typechan_result_wpm_bucket{mode="timed",le="150"} 0
+typechan_result_wpm_bucket{mode="timed",le="+Inf"} 1
+typechan_result_wpm_sum{mode="timed"} 200
+typechan_result_wpm_count{mode="timed"} 1
+# HELP typechan_result_accuracy Accuracy of the results accepted, range 0 to 1.
+# TYPE typechan_result_accuracy histogram
+typechan_result_accuracy_bucket{mode="sprint",le="0.5"} 0
+typechan_result_accuracy_bucket{mode="sprint",le="0.8"} 0
+typechan_result_accuracy_bucket{mode="sprint",le="0.9"} 0
+typechan_result_accuracy_bucket{mode="sprint",le="0.95"} 0
+typechan_result_accuracy_bucket{mode="sprint",le="0.98"} 1
+typechan_result_accuracy_bucket{mode="sprint",le="0.99"} 1
+typechan_result_accuracy_bucket{mode="sprint",le="1"} 2
+typechan_result_accuracy_bucket{mode="sprint",le="+Inf"} 2
+typechan_result_accuracy_sum{mode="sprint"} 1.97
+typechan_result_accuracy_count{mode="sprint"} 2
+typechan_result_accuracy_bucket{mode="timed",le="0.5"} 1
+typechan_result_accuracy_bucket{mode="timed",le="0.8"} 1
+typechan_result_accuracy_bucket{mode="timed",le="0.9"} 1
+typechan_result_accuracy_bucket{mode="timed",le="0.95"} 1
+typechan_result_accuracy_bucket{mode="timed",le="0.98"} 1
+typechan_result_accuracy_bucket{mode="timed",le="0.99"} 1
+typechan_result_accuracy_bucket{mode="timed",le="1"} 1
+typechan_result_accuracy_bucket{mode="timed",le="+Inf"} 1
+typechan_result_accuracy_sum{mode="timed"} 0.4
+typechan_result_accuracy_count{mode="timed"} 1
+# HELP typechan_active_sessions Number of clients submitting to the leaderboard running, heard of in the last 30m0s.
+# TYPE typechan_active_sessions gauge
+typechan_active_sessions 1
+# HELP typechan_text_fetch_errors_total Number of texts clients failed to fetch from their source, by mode.
+# TYPE typechan_text_fetch_errors_total counter
+typechan_text_fetch_errors_total{mode="sprint"} 1
+typechan_text_fetch_errors_total{mode="timed"} 2
+`
+	if string(body) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", body, want)
+	}
+}
+
+func TestMetricsOutputEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := newMetricsRegistry().write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if !strings.HasPrefix(line, "# ") && line != "typechan_active_sessions 0" {
+			t.Errorf("got sample %q with nothing recorded", line)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutputFormats are the formats results can be written in.
+var OutputFormats = []string{"json", "csv"}
+
+// CheckOutputFormat tells if results can be written in the given format.
+func CheckOutputFormat(format string) error {
+	for _, f := range OutputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q, must be one of: %s", format, strings.Join(OutputFormats, ", "))
+}
+
+// WriteResults writes the results in the given format: one JSON object
+// per line for json, a header and a row per result for csv.
+func WriteResults(w io.Writer, format string, results []Result) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		for _, r := range results {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		return writeResultsCSV(w, results)
+	}
+	return CheckOutputFormat(format)
+}
+
+// keyName returns the letter, or the name of the key typing it if it's
+// a whitespace.
+func keyName(letter string) string {
+	switch letter {
+	case " ":
+		return "space"
+	case "\n":
+		return "enter"
+	}
+	return letter
+}
+
+// csvHeader is the header of results written as CSV.
+var csvHeader = []string{
+	"date", "mode", "errorPolicy", "layout", "elapsedSeconds", "durationSeconds", "failed",
+	"skipAhead", "backspaceWords", "seed",
+	"textIds", "authors", "sources", "tags", // tags of each text joined with ","
+	"totalKeysPressed", "correctKeysPressed", "uncorrectedErrors",
+	"grossWPM", "accuracy", "adjustedWPM", "cpm",
+	"backspaces", "meanLatencyMs", "missed", // missed as space-separated key:count pairs
+	"flags",
+}
+
+// writeResultsCSV writes the results as CSV, with a header. Lists, e.g.
+// of the texts typed, are joined with "|". The content of the texts and
+// the keystroke log are left out, see the JSON format for those.
+func writeResultsCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		var ids, authors, sources, tags []string
+		for _, text := range r.Texts {
+			ids = append(ids, text.ID)
+			authors = append(authors, text.Author)
+			sources = append(sources, text.Source)
+			tags = append(tags, strings.Join(text.Tags, ","))
+		}
+
+		missed := []string{}
+		for letter, count := range r.Keystrokes.Missed {
+			missed = append(missed, fmt.Sprintf("%s:%d", keyName(letter), count))
+		}
+		sort.Strings(missed)
+
+		err := writer.Write([]string{
+			r.Date.Format(time.RFC3339),
+			r.Mode,
+			r.ErrorPolicy,
+			r.Layout,
+			formatFloat(r.Elapsed.Seconds(), 3),
+			formatFloat(r.Duration.Seconds(), 3),
+			strconv.FormatBool(r.Failed),
+			strconv.FormatBool(r.SkipAhead),
+			strconv.FormatBool(r.BackspaceWords),
+			strconv.FormatInt(r.Seed, 10),
+			strings.Join(ids, "|"),
+			strings.Join(authors, "|"),
+			strings.Join(sources, "|"),
+			strings.Join(tags, "|"),
+			strconv.Itoa(r.TotalKeysPressed),
+			strconv.Itoa(r.CorrectKeysPressed),
+			strconv.Itoa(r.UncorrectedErrors),
+			formatFloat(r.GrossWPM, 2),
+			formatFloat(r.Accuracy, 4),
+			formatFloat(r.AdjustedWPM, 2),
+			formatFloat(r.CPM, 2),
+			strconv.Itoa(r.Keystrokes.Backspaces),
+			strconv.FormatInt(r.Keystrokes.MeanLatency.Milliseconds(), 10),
+			strings.Join(missed, " "),
+			strings.Join(r.Flags, "|"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatFloat formats a number for output, rounded to the given number of decimals.
+func formatFloat(f float64, decimals int) string {
+	return strconv.FormatFloat(f, 'f', decimals, 64)
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	_ "embed"
+	"math"
+	"strings"
+)
+
+//go:embed words.txt
+var wordList string
+
+// practiceWords returns the deduplicated words of the embedded word list.
+func practiceWords() []string {
+	seen := map[string]bool{}
+	words := []string{}
+	for _, word := range strings.Fields(wordList) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// adaptiveSource generates practice text that over-represents the keys
+// and bigrams the user is weakest at, according to the recorded key
+// statistics. Since statistics are reloaded on every call, the text
+// adjusts after every run.
+type adaptiveSource struct {
+	words []string
+}
+
+func (s *adaptiveSource) next(ctx context.Context) (quote, error) {
+	stats, err := loadKeyStats()
+	if err != nil {
+		return quote{}, err
+	}
+	weaknesses := stats.weaknesses()
+
+	// weight each word by how weak its letters and transitions are
+	weights := make([]float64, len(s.words))
+	totalWeight := 0.0
+	for i, word := range s.words {
+		weights[i] = math.Pow(wordWeakness(word, weaknesses), practiceBias)
+		totalWeight += weights[i]
+	}
+
+	rng := textRand(ctx)
+	q := generateText(practiceWordCount, func() string {
+		target := rng.Float64() * totalWeight
+		for i, weight := range weights {
+			target -= weight
+			if target <= 0 {
+				return s.words[i]
+			}
+		}
+		return s.words[len(s.words)-1]
+	})
+	q.Source = "Adaptive practice"
+	return q, nil
+}
+
+// wordWeakness returns the average weakness of the letters and bigrams
+// that make up the word, including the space that follows it.
+func wordWeakness(word string, weaknesses map[string]float64) float64 {
+	letters := []rune(word + " ")
+	total := 0.0
+	count := 0
+	for i, letter := range letters {
+		units := []string{string(letter)}
+		if i > 0 {
+			units = append(units, string(letters[i-1:i+1]))
+		}
+		for _, unit := range units {
+			weakness, ok := weaknesses[unit]
+			if !ok {
+				weakness = 1 // unrecorded keys are treated as average
+			}
+			total += weakness
+			count++
+		}
+	}
+	return total / float64(count)
+}
+
+// NewAdaptiveSource returns a TextSource that generates practice text
+// targeting the user's weakest keys and bigrams.
+func NewAdaptiveSource() TextSource {
+	return &adaptiveSource{words: practiceWords()}
+}
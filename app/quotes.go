@@ -2,93 +2,140 @@ package app
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"crypto/sha1"
+	"encoding/hex"
 	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
-// quoteFetcher handles querying quotes from external source.
+// quoteFetcher fetches quotes in the background, delivering them to
+// the typing page as messages so that slow sources never block the UI.
 type quoteFetcher struct {
-	quotes chan quote
-	error  chan error
-	ctx    context.Context
-	stop   context.CancelFunc
+	source   TextSource
+	fallback TextSource // used whenever source fails
+	fetching bool       // tells if a fetch is in flight
+	ctx      context.Context
+	stop     context.CancelFunc
+}
+
+// quoteMsg delivers a fetched quote. If the source failed, err tells
+// why and the quote comes from the fallback source instead, unless
+// that failed too, in which case the quote is empty.
+type quoteMsg struct {
+	fetcher *quoteFetcher
+	quote   quote
+	err     error
 }
 
-// start starts a goroutine that fetches quotes perpetually until
-// it is explicitly stopped. Fetched quotes are then enqueued inside
-// the quotes channel.
-func (q *quoteFetcher) start(buffer int) {
-	go func() {
-		for {
-			quote, err := getRandomQuote()
-			select {
-			case q.quotes <- quote:
-			case q.error <- err:
-			case <-q.ctx.Done():
-				// stop
-				close(q.quotes)
-				return
-			}
+// fetch returns a command that fetches the next quote, or nil if a
+// fetch is already in flight.
+func (q *quoteFetcher) fetch() tea.Cmd {
+	if q.fetching {
+		return nil
+	}
+	q.fetching = true
+	return q.next
+}
 
+// next fetches the next quote, from the fallback if the source fails.
+// Returns nil if the fetcher was stopped meanwhile.
+func (q *quoteFetcher) next() tea.Msg {
+	msg := quoteMsg{fetcher: q}
+	msg.quote, msg.err = q.source.next(q.ctx)
+	if msg.err != nil {
+		var err error
+		if msg.quote, err = q.fallback.next(q.ctx); err != nil {
+			msg.quote = quote{}
 		}
-	}()
+	}
+
+	if q.ctx.Err() != nil {
+		// stopped while fetching
+		return nil
+	}
+	return msg
+}
+
+// received marks the fetch in flight as done.
+func (q *quoteFetcher) received() {
+	q.fetching = false
 }
 
 // newQuoteFetcher returns a new instance of quoteFetcher.
-func newQuoteFetcher(ctx context.Context) *quoteFetcher {
+func newQuoteFetcher(ctx context.Context, source TextSource) *quoteFetcher {
 	cancelCtx, cancel := context.WithCancel(ctx)
 
+	if _, given := source.(*textsSource); !given {
+		// texts given explicitly are typed as they are
+		source = &filteringSource{source: source}
+	}
 	return &quoteFetcher{
-		quotes: make(chan quote, quoteBufferSize),
-		error:  make(chan error, 1),
-		ctx:    cancelCtx,
-		stop:   cancel,
+		source:   source,
+		fallback: newWordsSource(),
+		ctx:      cancelCtx,
+		stop:     cancel,
 	}
 }
 
 // the quote model
 type quote struct {
-	Text   string `json:"content"`
+	ID     string   `json:"_id,omitempty"`
+	Text   string   `json:"content"`
+	Author string   `json:"author,omitempty"`
+	Source string   `json:"source,omitempty"` // title of the work, or name of the generator the text comes from
+	Tags   []string `json:"tags,omitempty"`
 	length int
-}
 
-// getRandomQuote queries a random quote from the API.
-func getRandomQuote() (quote, error) {
-	url := "https://api.quotable.io/random?minLength=100"
-	var quote quote
+	generated bool // made up by the source rather than picked from a collection
+}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return quote, err
+// id returns the identifier of the quote, or one derived from its text
+// if it has none.
+func (q quote) id() string {
+	if q.ID != "" {
+		return q.ID
 	}
+	sum := sha1.Sum([]byte(q.Text))
+	return hex.EncodeToString(sum[:8])
+}
 
-	if resp.StatusCode != 200 {
-		return quote, fmt.Errorf("API returns code %v: %s", resp.StatusCode, resp.Status)
-	}
+// info returns the description of the quote, to be kept with results.
+func (q quote) info() TextInfo {
+	return TextInfo{ID: q.id(), Author: q.Author, Source: q.Source, Tags: q.Tags, Content: q.Text}
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return quote, err
-	}
-	defer resp.Body.Close()
+// TextInfo describes a text typed in a test.
+type TextInfo struct {
+	ID     string   `json:"id"`
+	Author string   `json:"author,omitempty"`
+	Source string   `json:"source,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
 
-	err = json.Unmarshal(body, &quote)
-	if err != nil {
-		return quote, err
-	}
+	Content string `json:"content,omitempty"` // the text itself, as it was typed
+}
 
-	quote.Text, quote.length = processText(quote.Text)
-	return quote, nil
+// attribution returns the author and source of the text, e.g.
+// "— Albert Einstein, Relativity", or an empty string if both are unknown.
+func (t TextInfo) attribution() string {
+	credits := []string{}
+	for _, credit := range []string{t.Author, t.Source} {
+		if credit != "" {
+			credits = append(credits, credit)
+		}
+	}
+	if len(credits) == 0 {
+		return ""
+	}
+	return "— " + strings.Join(credits, ", ")
 }
 
 // processText processes the quote by substituting unicode characters
 // with its equivalent ASCII representation, and removes all unicode
 // characters, tabs, newlines from the string.
 func processText(text string) (string, int) {
-	filtered := ""
+	var filtered strings.Builder
+	filtered.Grow(len(text))
 	for _, rune := range text {
 		// replace non-ASCII letter
 		if replacement, ok := unicodeSubstitute[rune]; ok {
@@ -97,13 +144,13 @@ func processText(text string) (string, int) {
 
 		// remove non-ASCII letter and newline character
 		if isASCII(rune) && rune != '\n' {
-			filtered += string(rune)
+			filtered.WriteRune(rune)
 		}
 	}
 
 	// remove redundant whitespaces, tabs, newlines
-	filtered = strings.Join(strings.Fields(filtered), " ")
-	return filtered, len(filtered)
+	result := strings.Join(strings.Fields(filtered.String()), " ")
+	return result, len(result)
 }
 
 // unicodeSubstitute maps unicode character to its equivalent/similar
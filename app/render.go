@@ -0,0 +1,188 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"typechan/engine"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// textarea renders the text of a test.
+type textarea struct {
+	*engine.Text
+
+	height      int                  // number of lines visible at once, scrolling to keep the cursor in the middle; 0 shows all lines
+	renderCache map[int]renderedLine // rendered lines, keyed by line index
+}
+
+// newTextarea returns a new instance of textarea, rendering the given text.
+func newTextarea(text *engine.Text) *textarea {
+	return &textarea{
+		Text:        text,
+		renderCache: map[int]renderedLine{},
+	}
+}
+
+// append appends a quote to the text.
+func (t *textarea) append(q quote) error {
+	changedFrom, err := t.Append(q.Text)
+	if err != nil {
+		return err
+	}
+	t.dropRenderCache(changedFrom)
+	return nil
+}
+
+// resize re-splits the text according to the current resized window.
+func (t *textarea) resize() {
+	t.Resize(appWidth)
+	t.dropRenderCache(0)
+}
+
+// letterStyles are the styles of each letter kind, shared by all renders.
+var letterStyles = map[engine.LetterKind]lipgloss.Style{
+	engine.TypedLetter:          lipgloss.NewStyle().Foreground(grey),
+	engine.ErrorLetter:          lipgloss.NewStyle().Foreground(red),
+	engine.CursorLetter:         lipgloss.NewStyle().Underline(true),
+	engine.MistypedCursorLetter: lipgloss.NewStyle().Underline(true).Background(red),
+	engine.MistypedLetter:       lipgloss.NewStyle().Background(red),
+}
+
+// indicatorStyle is the style of the indicator of text left below the viewport.
+var indicatorStyle = lipgloss.NewStyle().Foreground(grey)
+
+// extraStyle is the style of extra letters typed beyond the end of a word.
+var extraStyle = lipgloss.NewStyle().Foreground(red).Strikethrough(true)
+
+// renderedLine is a cached render of a line the cursor is not on.
+type renderedLine struct {
+	typed bool // rendered before the cursor reached past it
+	view  string
+}
+
+// visibleLines returns the range [first, last) of line indices to render,
+// keeping the current line in the middle of the viewport where possible.
+func (t *textarea) visibleLines() (int, int) {
+	lineCount := len(t.Lines())
+	if t.height <= 0 || t.height >= lineCount {
+		return 0, lineCount
+	}
+
+	first := t.CursorLine() - (t.height-1)/2
+	if first > lineCount-t.height {
+		first = lineCount - t.height
+	}
+	if first < 0 {
+		first = 0
+	}
+	return first, first + t.height
+}
+
+// remainingIndicator returns how much text is left below the viewport,
+// or an empty string if it all fits.
+func (t *textarea) remainingIndicator() string {
+	_, last := t.visibleLines()
+	hidden := len(t.Lines()) - last
+	if hidden <= 0 {
+		return ""
+	}
+
+	if hidden == 1 {
+		return "↓ 1 more line"
+	}
+	return fmt.Sprintf("↓ %d more lines", hidden)
+}
+
+func (t *textarea) View() string {
+	var b strings.Builder
+	first, last := t.visibleLines()
+
+	// lines the cursor and mistypes lie on change with every keypress,
+	// the others only change when the cursor moves past them
+	cursorLine := t.CursorLine()
+	lastActive := t.LineAt(t.Typed() + t.Mistyped())
+
+	for lineIndex := first; lineIndex < last; lineIndex++ {
+		b.WriteString(strings.Repeat(" ", paddingX))
+
+		typed := lineIndex < cursorLine
+		active := lineIndex >= cursorLine && lineIndex <= lastActive
+		if cached, ok := t.renderCache[lineIndex]; ok && !active && cached.typed == typed {
+			b.WriteString(cached.view)
+		} else {
+			view := t.renderLine(lineIndex)
+			if active {
+				delete(t.renderCache, lineIndex)
+			} else {
+				t.renderCache[lineIndex] = renderedLine{typed: typed, view: view}
+			}
+			b.WriteString(view)
+		}
+
+		b.WriteByte('\n')
+	}
+
+	if indicator := t.remainingIndicator(); indicator != "" {
+		b.WriteString(strings.Repeat(" ", paddingX))
+		b.WriteString(indicatorStyle.Render(indicator))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// renderLine renders a line, styling runs of letters of the same kind
+// together rather than letter by letter.
+func (t *textarea) renderLine(lineIndex int) string {
+	var b, run strings.Builder
+	runKind := engine.UntypedLetter
+
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if runKind == engine.UntypedLetter {
+			// no styling applied for untyped letters that come after current letter
+			b.WriteString(run.String())
+		} else {
+			b.WriteString(letterStyles[runKind].Render(run.String()))
+		}
+		run.Reset()
+	}
+
+	line := t.Lines()[lineIndex]
+	lineOffset := t.LineOffset(lineIndex)
+	for i := 0; i < len(line); i++ {
+		offset := lineOffset + i
+
+		if extra := t.Extra(offset); extra != "" {
+			// extra letters typed beyond the end of the previous word
+			flush()
+			b.WriteString(extraStyle.Render(extra))
+		}
+
+		if kind := t.LetterKindAt(offset); kind != runKind {
+			flush()
+			runKind = kind
+		}
+
+		if line[i] == '\n' {
+			run.WriteString("⏎")
+		} else {
+			run.WriteByte(line[i])
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+// dropRenderCache drops the render cache of the lines from the given
+// index onwards.
+func (t *textarea) dropRenderCache(from int) {
+	for lineIndex := range t.renderCache {
+		if lineIndex >= from {
+			delete(t.renderCache, lineIndex)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+	"typechan/engine"
+)
+
+// benchmarkText returns a text of 3000 words of the word list, with
+// curly quotes to substitute.
+func benchmarkText() string {
+	rng := rand.New(rand.NewSource(1))
+	words := practiceWords()
+	picked := []string{}
+	for i := 0; i < 3000; i++ {
+		word := words[rng.Intn(len(words))]
+		if i%50 == 0 {
+			word = "‘" + word + "’"
+		}
+		picked = append(picked, word)
+	}
+	return strings.Join(picked, " ")
+}
+
+// newBenchmarkTextarea returns a textarea of the benchmark text wrapped
+// at width 80, showing the given number of lines.
+func newBenchmarkTextarea(height int) (*engine.Test, *textarea) {
+	test := engine.NewTest(engine.Options{Policy: engine.Lenient, Width: 80})
+	t := newTextarea(test.Text())
+	t.height = height
+	q := quote{}
+	q.Text, q.length = processText(benchmarkText())
+	t.append(q)
+	return test, t
+}
+
+func BenchmarkProcessText(b *testing.B) {
+	text := benchmarkText()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		processText(text)
+	}
+}
+
+func BenchmarkViewAllLines(b *testing.B) {
+	_, t := newBenchmarkTextarea(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.View()
+	}
+}
+
+func BenchmarkViewScrolling(b *testing.B) {
+	_, t := newBenchmarkTextarea(5)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.View()
+	}
+}
+
+// BenchmarkTypeAndView types 300 keys of the text, mistyping every tenth
+// one, rendering all lines after each key as the typing page does.
+func BenchmarkTypeAndView(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		test, t := newBenchmarkTextarea(0)
+		text := t.Lines()[0] + t.Lines()[1] + t.Lines()[2] + t.Lines()[3] + t.Lines()[4]
+		now := time.Now()
+		b.StartTimer()
+
+		for j := 0; j < 300; j++ {
+			key := engine.Key{Kind: engine.LetterKey, Letter: text[j : j+1]}
+			switch {
+			case text[j] == ' ':
+				key = engine.Key{Kind: engine.SpaceKey}
+			case j%10 == 9:
+				key.Letter = "#"
+			}
+			test.Press(key, now)
+			t.View()
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"time"
+	"typechan/engine"
+)
+
+// Result is the outcome of a typing test.
+type Result struct {
+	Date        time.Time     `json:"date"`
+	Mode        string        `json:"mode"`
+	ErrorPolicy string        `json:"errorPolicy"`
+	Layout      string        `json:"layout,omitempty"` // keyboard layout emulated, if any
+	Elapsed     time.Duration `json:"elapsed"`
+	Failed      bool          `json:"failed,omitempty"`   // the test was ended early by the error policy
+	Duration    time.Duration `json:"duration,omitempty"` // time limit, in Timed mode
+	Texts       []TextInfo    `json:"texts,omitempty"`
+	Seed        int64         `json:"seed,omitempty"` // seed the texts of the test were picked with
+
+	SkipAhead      bool `json:"skipAhead,omitempty"`
+	BackspaceWords bool `json:"backspaceWords,omitempty"`
+
+	TotalKeysPressed   int `json:"totalKeysPressed"`
+	CorrectKeysPressed int `json:"correctKeysPressed"`
+	UncorrectedErrors  int `json:"uncorrectedErrors"`
+
+	engine.Metrics
+	Keystrokes engine.KeystrokeSummary `json:"keystrokes"`
+	KeyLog     []engine.LoggedKey      `json:"keyLog,omitempty"` // to replay the test, e.g. to check its metrics
+	Flags      []string                `json:"flags,omitempty"`  // reasons to doubt the test was typed by hand, see engine.CheckLog
+}
+
+// counts tells if the result counts towards bests and progress, i.e. it
+// wasn't failed nor flagged.
+func (r Result) counts() bool {
+	return !r.Failed && len(r.Flags) == 0
+}
+
+// options returns the rules the test was taken with.
+func (r Result) options() (engine.Options, error) {
+	policy, err := engine.ParseErrorPolicy(r.ErrorPolicy)
+	if err != nil {
+		return engine.Options{}, err
+	}
+	return engine.Options{
+		Policy:         policy,
+		SkipAhead:      r.SkipAhead,
+		BackspaceWords: r.BackspaceWords,
+		Width:          replayWidth,
+	}, nil
+}
+
+// computeMetrics computes the speed and accuracy metrics of the result
+// from its key counts and elapsed time.
+func (r *Result) computeMetrics() {
+	r.Metrics = engine.ComputeMetrics(r.TotalKeysPressed, r.CorrectKeysPressed, r.Elapsed)
+}
@@ -11,29 +11,87 @@ import (
 
 // resultPage is the page model for typing results.
 type resultPage struct {
-	app *app
+	app    *app
+	result Result
 
-	totalKeysPressed   int
-	correctKeysPressed int
-	elapsedTime        time.Duration
+	message  string // shown below the stats, if any
+	fallback bool   // whether any text typed came from the fallback source rather than the test's
 
-	grossWPM    float64
-	accuracy    float64
-	adjustedWPM float64
-	cpm         float64
+	best    Result // best past result on the same text
+	hasBest bool
+
+	sinks      []resultSink
+	sending    bool         // whether the result is being sent to the sinks
+	sent       chan sentMsg // receives the outcome once the result is sent
+	sinkErrors sinkErrors   // errors of the sinks the result failed to be sent to
+}
+
+// sentMsg is sent once a result has been sent to the sinks.
+type sentMsg struct {
+	page *resultPage
+	errs sinkErrors
 }
 
 func (r *resultPage) init() error {
-	// https://support.sunburst.com/hc/en-us/articles/229335208-Type-to-Learn-How-are-Words-Per-Minute-and-Accuracy-Calculated-
-	r.grossWPM = (float64(r.totalKeysPressed) / 5) / r.elapsedTime.Minutes()
-	r.accuracy = (float64(r.correctKeysPressed) / float64(r.totalKeysPressed)) // range 0 to 1
-	r.adjustedWPM = r.grossWPM * r.accuracy
-	r.cpm = float64(r.totalKeysPressed) / r.elapsedTime.Minutes()
+	r.result.computeMetrics()
+
+	// texts from the fallback source aren't the ones the listener tracks
+	// progress on, e.g. a lesson's drills
+	if listener, ok := r.app.source.(resultListener); ok && r.result.counts() && !r.fallback {
+		message, err := listener.onResult(r.result.AdjustedWPM, r.result.Accuracy)
+		if err != nil {
+			return err
+		}
+		r.message = message
+	} else if ok && r.fallback {
+		r.message = "Local words were typed instead of the test's text: this result doesn't count."
+	}
+	r.app.lastResult = &r.result
+
+	if len(r.result.Texts) == 1 {
+		history, err := loadHistory()
+		if err != nil {
+			return err
+		}
+		r.best, r.hasBest = bestOnText(history, r.result.Texts[0].ID)
+	}
+	if err := appendHistory(r.result); err != nil {
+		return err
+	}
+
+	sinks, err := parseSinks()
+	if err != nil {
+		return err
+	}
+	if len(sinks) > 0 {
+		// sent in the background rather than by a command, which the
+		// program may never run if it quits first, so that the app
+		// doesn't wait on exit for a result never sent
+		r.sinks = sinks
+		r.sending = true
+		r.sent = make(chan sentMsg, 1)
+		r.app.sending.Add(1)
+		go func() {
+			defer r.app.sending.Done()
+			r.sent <- sentMsg{page: r, errs: sendToSinks(r.sinks, r.result)}
+		}()
+	}
 	return nil
 }
 
+// waitSent waits for the result to be sent to the sinks, for the page to
+// be told with a sentMsg.
+func (r *resultPage) waitSent() tea.Msg {
+	return <-r.sent
+}
+
 func (r *resultPage) update(msg tea.Msg) (tea.Cmd, error) {
 	switch msg := msg.(type) {
+	case sentMsg:
+		if msg.page == r {
+			r.sending = false
+			r.sinkErrors = msg.errs
+		}
 	case tea.KeyMsg:
 		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
 			// exit
@@ -46,15 +104,52 @@ func (r *resultPage) update(msg tea.Msg) (tea.Cmd, error) {
 }
 
 func (r *resultPage) view() string {
-	statStr := fmt.Sprintf("Gross WPM: %.2f\n", r.grossWPM)
-	statStr += fmt.Sprintf("Accuracy: %.2f%%\n", r.accuracy*100)
-	statStr += fmt.Sprintf("Adjusted WPM: %.2f\n\n", r.adjustedWPM)
+	statStr := ""
+	if r.result.Failed {
+		statStr += lipgloss.NewStyle().Foreground(red).Render("Test failed: "+r.result.ErrorPolicy) + "\n\n"
+	}
+	if len(r.result.Flags) > 0 {
+		statStr += lipgloss.NewStyle().Foreground(red).Render("Result flagged: "+strings.Join(r.result.Flags, ", ")) + "\n\n"
+	}
+
+	statStr += fmt.Sprintf("Gross WPM: %.2f\n", r.result.GrossWPM)
+	statStr += fmt.Sprintf("Accuracy: %.2f%%\n", r.result.Accuracy*100)
+	statStr += fmt.Sprintf("Adjusted WPM: %.2f\n\n", r.result.AdjustedWPM)
+
+	statStr += fmt.Sprintf("Time: %v\n", r.result.Elapsed.Round(10*time.Millisecond))
+	statStr += fmt.Sprintf("CPM: %.2f\n\n", r.result.CPM)
 
-	statStr += fmt.Sprintf("Time: %v\n", r.elapsedTime.Round(10*time.Millisecond))
-	statStr += fmt.Sprintf("CPM: %.2f\n\n", r.cpm)
+	statStr += fmt.Sprintf("Total keys pressed: %d\n", r.result.TotalKeysPressed)
+	statStr += fmt.Sprintf("Correct keys: %d\n", r.result.CorrectKeysPressed)
+	statStr += fmt.Sprintf("Uncorrected errors: %d\n", r.result.UncorrectedErrors)
+	statStr += fmt.Sprintf("Error policy: %s", r.result.ErrorPolicy)
 
-	statStr += fmt.Sprintf("Total keys pressed: %d\n", r.totalKeysPressed)
-	statStr += fmt.Sprintf("Correct keys: %d", r.correctKeysPressed)
+	credits := []string{}
+	for _, text := range r.result.Texts {
+		if attribution := text.attribution(); attribution != "" {
+			credits = append(credits, attribution)
+		}
+	}
+	if len(credits) > 0 {
+		statStr += "\n\n" + lipgloss.NewStyle().Foreground(grey).Render(strings.Join(credits, "\n"))
+	}
+
+	if r.hasBest && r.result.AdjustedWPM > r.best.AdjustedWPM && r.result.counts() {
+		statStr += fmt.Sprintf("\n\nNew best on this text! Previously %.2f WPM", r.best.AdjustedWPM)
+	} else if r.hasBest {
+		statStr += fmt.Sprintf("\n\nBest on this text: %.2f WPM on %s", r.best.AdjustedWPM, r.best.Date.Format("2006-01-02"))
+	}
+
+	if r.message != "" {
+		statStr += "\n\n" + r.message
+	}
+
+	if r.sending {
+		statStr += "\n\n" + lipgloss.NewStyle().Foreground(grey).Render("sending result...")
+	}
+	for _, err := range r.sinkErrors {
+		statStr += "\n\n" + lipgloss.NewStyle().Foreground(red).Render("Failed to send result to "+err.Error())
+	}
 
 	return lipgloss.NewStyle().PaddingLeft(paddingX).Render(statStr) + "\n\n" +
 		strings.Repeat(" ", paddingX) + lipgloss.NewStyle().Foreground(grey).Render("enter to restart") + "\n" +
@@ -62,17 +157,9 @@ func (r *resultPage) view() string {
 }
 
 // newResultPage returns a new instance of resultPage.
-func newResultPage(
-	app *app,
-	totalKeysPressed int,
-	correctKeysPressed int,
-	elapsedTime time.Duration,
-) *resultPage {
-
+func newResultPage(app *app, result Result) *resultPage {
 	return &resultPage{
-		app:                app,
-		totalKeysPressed:   totalKeysPressed,
-		correctKeysPressed: correctKeysPressed,
-		elapsedTime:        elapsedTime,
+		app:    app,
+		result: result,
 	}
 }
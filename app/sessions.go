@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Events of a session reported to a leaderboard server.
+const (
+	sessionStarted     = "start"       // a test is started, the session being active from then on
+	sessionEnded       = "end"         // the program quits
+	sessionFetchFailed = "fetch_error" // a text failed to be fetched from the source of the test
+)
+
+// sessionEvent is an event of the session of a client, reported to the
+// leaderboard server for it to count the clients running and the texts
+// they failed to fetch.
+type sessionEvent struct {
+	Session string `json:"session"` // ID of the session, picked at random by the client
+	Event   string `json:"event"`
+	Mode    string `json:"mode"`
+}
+
+// sessionReporter reports the events of the session to the leaderboard
+// server at SubmitURL, in the background and in order. Reports are a
+// best effort: those failing are dropped.
+type sessionReporter struct {
+	url    string
+	id     string
+	client *http.Client
+	events chan sessionEvent
+	done   chan struct{} // closed once all the events are reported
+}
+
+// report reports the event in the given mode in the background. Does
+// nothing if s is nil, i.e. results aren't submitted to a leaderboard.
+func (s *sessionReporter) report(event string, mode Mode) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.events <- sessionEvent{Session: s.id, Event: event, Mode: mode.String()}:
+	default:
+		// the server is too slow to keep up with
+	}
+}
+
+// run reports the events until the session ends.
+func (s *sessionReporter) run() {
+	defer close(s.done)
+	for e := range s.events {
+		ctx, cancel := context.WithTimeout(context.Background(), sessionReportTimeout)
+		s.send(ctx, e)
+		cancel()
+	}
+}
+
+// send reports the event to the server.
+func (s *sessionReporter) send(ctx context.Context, e sessionEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, leaderboardURL(s.url, "/sessions"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("leaderboard returns code %v: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// end reports the end of the session, waiting up to sessionReportTimeout
+// for the events left to be reported. Does nothing if s is nil.
+func (s *sessionReporter) end() {
+	if s == nil {
+		return
+	}
+	s.report(sessionEnded, currentMode)
+	close(s.events)
+	select {
+	case <-s.done:
+	case <-time.After(sessionReportTimeout):
+	}
+}
+
+// newSessionReporter returns a new instance of sessionReporter,
+// reporting to SubmitURL, or nil if it's not set.
+func newSessionReporter() (*sessionReporter, error) {
+	if SubmitURL == "" {
+		return nil, nil
+	}
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	s := &sessionReporter{
+		url:    SubmitURL,
+		id:     hex.EncodeToString(id),
+		client: client,
+		events: make(chan sessionEvent, sessionMaxPending),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
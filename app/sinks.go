@@ -0,0 +1,183 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Sinks are the sinks the result of every test completed is sent to,
+// each given as kind=target, see parseSink.
+var Sinks []string
+
+// resultSink is where results are sent to once a test is completed.
+type resultSink interface {
+	fmt.Stringer
+
+	// send sends the result, giving up once ctx is done.
+	send(ctx context.Context, r Result) error
+}
+
+// parseSink returns the sink of the given specification, one of:
+//
+//	webhook=URL    POSTs the result as JSON to the URL
+//	file=PATH      appends the result as a line of JSON to the file
+//	command=CMD    runs the command with the shell, the result as JSON on stdin
+func parseSink(spec string) (resultSink, error) {
+	kind, target, ok := strings.Cut(spec, "=")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("invalid sink %q, must be kind=target", spec)
+	}
+
+	switch kind {
+	case "webhook":
+		client, err := newHTTPClient()
+		if err != nil {
+			return nil, err
+		}
+		return &webhookSink{url: target, client: client}, nil
+	case "file":
+		return &fileSink{path: target}, nil
+	case "command":
+		return &commandSink{command: target}, nil
+	}
+	return nil, fmt.Errorf("unknown sink kind %q, must be one of: webhook, file, command", kind)
+}
+
+// parseSinks returns the sinks given by Sinks, and the leaderboard
+// given by SubmitURL.
+func parseSinks() ([]resultSink, error) {
+	sinks := []resultSink{}
+	for _, spec := range Sinks {
+		sink, err := parseSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if SubmitURL != "" {
+		sink, err := newLeaderboardSink()
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// CheckSinks tells if all the sinks given by Sinks and SubmitURL are valid.
+func CheckSinks() error {
+	_, err := parseSinks()
+	return err
+}
+
+// webhookSink POSTs results as JSON to a URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) String() string { return "webhook " + s.url }
+
+func (s *webhookSink) send(ctx context.Context, r Result) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returns code %v: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// fileSink appends results to a file, one JSON object per line.
+type fileSink struct {
+	path string
+}
+
+func (s *fileSink) String() string { return "file " + s.path }
+
+func (s *fileSink) send(ctx context.Context, r Result) error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := WriteResults(f, "json", []Result{r}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// commandSink runs a command with the shell, feeding it the result as
+// JSON on stdin.
+type commandSink struct {
+	command string
+}
+
+func (s *commandSink) String() string { return "command " + s.command }
+
+func (s *commandSink) send(ctx context.Context, r Result) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.command)
+	}
+	cmd.Stdin = bytes.NewReader(body)
+
+	// the command's output would garble the UI, keep it for the error only
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if output := strings.TrimSpace(string(output)); output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+// sinkErrors is the outcome of sending a result to the sinks: the errors
+// of the sinks that failed, if any.
+type sinkErrors []error
+
+// sendToSinks sends the result to every sink in turn, giving up on all
+// of them after sinkTimeout.
+func sendToSinks(sinks []resultSink, r Result) sinkErrors {
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+
+	errs := sinkErrors{}
+	for _, sink := range sinks {
+		if err := sink.send(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink, err))
+		}
+	}
+	return errs
+}
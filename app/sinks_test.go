@@ -0,0 +1,264 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sinkResult is the result sent to the sinks by the tests.
+var sinkResult = Result{
+	Date:               time.Date(2023, time.March, 4, 10, 30, 0, 0, time.UTC),
+	Mode:               "sprint",
+	ErrorPolicy:        "lenient",
+	Elapsed:            10 * time.Second,
+	Texts:              []TextInfo{{ID: "abc"}},
+	TotalKeysPressed:   50,
+	CorrectKeysPressed: 48,
+}
+
+// webhookStandIn records the results POSTed to it, answering with the
+// given status code.
+type webhookStandIn struct {
+	mu      sync.Mutex
+	status  int
+	results []Result
+}
+
+func (s *webhookStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	var result Result
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.results = append(s.results, result)
+	w.WriteHeader(s.status)
+}
+
+func TestWebhookSink(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusNoContent, http.StatusInternalServerError, http.StatusNotFound} {
+		standIn := &webhookStandIn{status: status}
+		server := httptest.NewServer(standIn)
+
+		sink, err := parseSink("webhook=" + server.URL + "/results")
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs := sendToSinks([]resultSink{sink}, sinkResult)
+		server.Close()
+
+		if wantErr := status >= 300; (len(errs) > 0) != wantErr {
+			t.Errorf("status %d: got errors %v", status, errs)
+		}
+		if len(standIn.results) != 1 || !standIn.results[0].Date.Equal(sinkResult.Date) || standIn.results[0].TotalKeysPressed != 50 {
+			t.Errorf("status %d: got results %+v", status, standIn.results)
+		}
+	}
+}
+
+func TestWebhookSinkTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	sink, err := parseSink("webhook=" + server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := sink.send(ctx, sinkResult); err == nil {
+		t.Error("got no error")
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	sink, err := parseSink("file=" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if errs := sendToSinks([]resultSink{sink}, sinkResult); len(errs) > 0 {
+			t.Fatal(errs)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), data)
+	}
+	for _, line := range lines {
+		var r Result
+		if err := json.Unmarshal([]byte(line), &r); err != nil || r.Mode != "sprint" {
+			t.Errorf("got line %s: %v", line, err)
+		}
+	}
+
+	sink, _ = parseSink("file=" + filepath.Join(t.TempDir(), "missing", "results.jsonl"))
+	if errs := sendToSinks([]resultSink{sink}, sinkResult); len(errs) != 1 {
+		t.Errorf("got errors %v writing into a missing directory", errs)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no shell to run commands with")
+	}
+	path := filepath.Join(t.TempDir(), "result.json")
+
+	sink, err := parseSink("command=cat > '" + path + "'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := sendToSinks([]resultSink{sink}, sinkResult); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil || r.TotalKeysPressed != 50 {
+		t.Errorf("got %s on stdin: %v", data, err)
+	}
+
+	sink, _ = parseSink("command=echo oops >&2; exit 3")
+	errs := sendToSinks([]resultSink{sink}, sinkResult)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "oops") {
+		t.Errorf("got errors %v, want the command's output", errs)
+	}
+}
+
+func TestParseSink(t *testing.T) {
+	for _, spec := range []string{"webhook", "webhook=", "ftp=example.com", "=file"} {
+		if _, err := parseSink(spec); err == nil {
+			t.Errorf("%q: got no error", spec)
+		}
+	}
+}
+
+func TestSendToSinksKeepsGoing(t *testing.T) {
+	standIn := &webhookStandIn{status: http.StatusOK}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	failing, _ := parseSink("file=" + filepath.Join(t.TempDir(), "missing", "results.jsonl"))
+	webhook, _ := parseSink("webhook=" + server.URL)
+	errs := sendToSinks([]resultSink{failing, webhook}, sinkResult)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "file ") {
+		t.Errorf("got errors %v", errs)
+	}
+	if len(standIn.results) != 1 {
+		t.Errorf("got %d results sent after a failing sink, want 1", len(standIn.results))
+	}
+}
+
+func TestResultPageFailingInit(t *testing.T) {
+	DataDir = t.TempDir()
+	defer func(sinks []string) { Sinks = sinks }(Sinks)
+	Sinks = []string{"file=" + filepath.Join(DataDir, "results.jsonl")}
+
+	// the history can't be read, so the result page fails to start
+	if err := os.WriteFile(filepath.Join(DataDir, historyFile), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := New()
+	a.source = NewTextsSource("abc")
+	if err := a.changePage(newResultPage(a, sinkResult)); err == nil {
+		t.Fatal("got no error")
+	}
+
+	// nothing is sent, so the app must not wait on exit
+	done := make(chan struct{})
+	go func() {
+		a.sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("app waits for a result that is never sent")
+	}
+}
+
+func TestResultPageSends(t *testing.T) {
+	DataDir = t.TempDir()
+	defer func(sinks []string) { Sinks = sinks }(Sinks)
+	path := filepath.Join(DataDir, "results.jsonl")
+	Sinks = []string{"file=" + path}
+
+	a := New()
+	a.source = NewTextsSource("abc")
+	page := newResultPage(a, sinkResult)
+	if err := a.changePage(page); err != nil {
+		t.Fatal(err)
+	}
+	if !page.sending {
+		t.Fatal("result not being sent")
+	}
+	page.update(page.waitSent())
+	a.sending.Wait()
+
+	if page.sending || len(page.sinkErrors) > 0 {
+		t.Errorf("got sending %v, errors %v", page.sending, page.sinkErrors)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("\n")); n != 1 {
+		t.Errorf("got %d results sent, want 1", n)
+	}
+}
+
+func TestResultPageQuitWhileSending(t *testing.T) {
+	DataDir = t.TempDir()
+	defer func(sinks []string) { Sinks = sinks }(Sinks)
+	Sinks = []string{"file=" + filepath.Join(DataDir, "results.jsonl")}
+
+	a := New()
+	a.source = NewTextsSource("abc")
+	if err := a.changePage(newResultPage(a, sinkResult)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the program quits before running the command waiting for the
+	// result, yet the result is sent and the app doesn't wait forever
+	if cmd, _ := a.currentPage.update(tea.KeyMsg{Type: tea.KeyEsc}); cmd == nil {
+		t.Fatal("got no command quitting")
+	}
+	done := make(chan struct{})
+	go func() {
+		a.sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("app waits for a result whose command never runs")
+	}
+}
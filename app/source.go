@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Seed is the seed the texts of the first test are picked at random
+// with, so that the same seed picks the same texts, given the same quote
+// cache and statistics. The seeds of the next tests of the session are
+// derived from it, see nextSeed. If 0, a seed is picked at the first use.
+var Seed int64
+
+// textSeed returns Seed, picking one first if it isn't set.
+func textSeed() int64 {
+	for Seed == 0 {
+		Seed = time.Now().UnixNano()
+	}
+	return Seed
+}
+
+// nextSeed returns the seed of the test after the one of the given seed,
+// or textSeed if there was none. Any seed picks the texts of its own
+// test again, whichever test of the session it was.
+func nextSeed(seed int64) int64 {
+	if seed == 0 {
+		return textSeed()
+	}
+	for {
+		if next := rand.New(rand.NewSource(seed)).Int63(); next != 0 {
+			return next
+		}
+		seed++
+	}
+}
+
+// textRandKey is the key of the random number generator picking texts in
+// a context.
+type textRandKey struct{}
+
+// withTextRand returns a copy of ctx carrying a random number generator
+// picking texts, seeded with the given seed. Every text of a test is
+// picked with the same generator, whichever source picks it.
+func withTextRand(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, textRandKey{}, rand.New(rand.NewSource(seed)))
+}
+
+// textRand returns the random number generator picking texts carried by
+// ctx, or a new one seeded from the clock if it carries none.
+func textRand(ctx context.Context) *rand.Rand {
+	if rng, ok := ctx.Value(textRandKey{}).(*rand.Rand); ok {
+		return rng
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// TextSource provides the texts to be typed in a test.
+type TextSource interface {
+	// next returns the next text to be typed, giving up once ctx is done.
+	next(ctx context.Context) (quote, error)
+}
+
+// quotableSource serves random quotes from the quotable API.
+type quotableSource struct {
+	client *quotableClient
+}
+
+func (s *quotableSource) next(ctx context.Context) (quote, error) {
+	return s.client.randomQuote(ctx)
+}
+
+// NewQuotableSource returns a TextSource that serves random quotes
+// from the quotable API, through the local quote cache.
+func NewQuotableSource() (TextSource, error) {
+	client, err := newQuotableClient()
+	if err != nil {
+		return nil, err
+	}
+	return newCachingSource(&quotableSource{client: client})
+}
+
+// resultListener is implemented by text sources that react to the
+// outcome of a test, e.g. to keep track of the user's progress.
+type resultListener interface {
+	// onResult is called once the test result has been computed, and
+	// returns a message to be shown alongside it.
+	onResult(wpm float64, accuracy float64) (string, error)
+}
+
+// startListener is implemented by text sources that react to a test
+// being started, i.e. its first key being pressed.
+type startListener interface {
+	onStart() error
+}
+
+// wordsSource serves random words from the embedded word list. It works
+// offline, and so serves as a fallback for the other sources.
+type wordsSource struct {
+	words []string
+}
+
+func (s *wordsSource) next(ctx context.Context) (quote, error) {
+	rng := textRand(ctx)
+	q := generateText(practiceWordCount, func() string {
+		return s.words[rng.Intn(len(s.words))]
+	})
+	q.Source = "Random words"
+	return q, nil
+}
+
+// newWordsSource returns a new instance of wordsSource.
+func newWordsSource() *wordsSource {
+	return &wordsSource{words: practiceWords()}
+}
+
+// textsSource serves the given texts in order, starting over once all
+// of them have been served.
+type textsSource struct {
+	texts []string
+	index int
+}
+
+func (s *textsSource) next(ctx context.Context) (quote, error) {
+	if len(s.texts) == 0 {
+		return quote{}, errors.New("no text to serve")
+	}
+
+	var q quote
+	q.Text, q.length = processText(s.texts[s.index%len(s.texts)])
+	s.index++
+	return q, nil
+}
+
+// NewTextsSource returns a TextSource that serves the given texts in
+// order, e.g. to drive a test with known texts. They are served whatever
+// the Filter.
+func NewTextsSource(texts ...string) TextSource {
+	return &textsSource{texts: texts}
+}
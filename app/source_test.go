@@ -0,0 +1,52 @@
+package app
+
+import "testing"
+
+// firstText returns the first text of the test of the typing page.
+func firstText(t *testing.T, page *typingPage) string {
+	t.Helper()
+	msg, ok := page.quoteFetcher.next().(quoteMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("got %+v fetching a text", msg)
+	}
+	return msg.quote.Text
+}
+
+func TestSeedPicksTextsAgain(t *testing.T) {
+	DataDir = t.TempDir()
+	defer func(seed int64) { Seed = seed }(Seed)
+	Seed = 42
+
+	// a session of three tests
+	a := New()
+	a.source = NewAdaptiveSource()
+	seeds, texts := []int64{}, []string{}
+	for i := 0; i < 3; i++ {
+		page := newTypingPage(a)
+		seeds = append(seeds, page.seed)
+		texts = append(texts, firstText(t, page))
+	}
+	if seeds[0] != 42 || seeds[1] == seeds[0] || seeds[2] == seeds[1] {
+		t.Fatalf("got seeds %v", seeds)
+	}
+	if texts[1] == texts[0] || texts[2] == texts[1] {
+		t.Fatalf("got the same texts in a row: %q", texts)
+	}
+
+	// the seed of any test picks its texts as the first of a session
+	for i, seed := range seeds {
+		Seed = seed
+		a := New()
+		a.source = NewAdaptiveSource()
+		page := newTypingPage(a)
+		if got := firstText(t, page); got != texts[i] {
+			t.Errorf("test %d: got %q with its seed, want %q", i, got, texts[i])
+		}
+		// and the tests after it follow on
+		if i+1 < len(seeds) {
+			if page := newTypingPage(a); page.seed != seeds[i+1] || firstText(t, page) != texts[i+1] {
+				t.Errorf("test %d: got seed %d after it, want %d", i+1, page.seed, seeds[i+1])
+			}
+		}
+	}
+}
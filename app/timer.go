@@ -8,22 +8,39 @@ import (
 
 type TickMsg time.Time
 
-// model for stopwatch
+// model for stopwatch, which can be paused
 type stopwatch struct {
-	startTime time.Time
+	clock     Clock
+	startTime time.Time     // start of the current run, zero if not running
+	previous  time.Duration // elapsed over previous runs
+	timeout   time.Duration // if set, a tick falls right on it
 }
 
 // start starts the stopwatch
 func (s *stopwatch) start() tea.Cmd {
+	s.startTime = s.clock.Now()
 	return s.tick()
 }
 
-// tick ticks the stopwatch at every 100ms interval.
+// pause pauses the stopwatch, ticks carry on.
+func (s *stopwatch) pause() {
+	s.previous = s.elapsed()
+	s.startTime = time.Time{}
+}
+
+// resume resumes the paused stopwatch.
+func (s *stopwatch) resume() {
+	s.startTime = s.clock.Now()
+}
+
+// tick ticks the stopwatch at every 100ms interval, or sooner if the
+// timeout falls before.
 func (s *stopwatch) tick() tea.Cmd {
-	return tea.Tick(100*time.Millisecond, func(curTime time.Time) tea.Msg {
-		if s.startTime.IsZero() {
-			s.startTime = time.Now()
-		}
+	interval := 100 * time.Millisecond
+	if remaining := s.timeout - s.elapsed(); remaining > 0 && remaining < interval {
+		interval = remaining
+	}
+	return s.clock.Tick(interval, func(curTime time.Time) tea.Msg {
 		return TickMsg(curTime)
 	})
 }
@@ -31,9 +48,9 @@ func (s *stopwatch) tick() tea.Cmd {
 // elapsed returns the elapsed duration.
 func (s *stopwatch) elapsed() time.Duration {
 	if s.startTime.IsZero() {
-		return 0
+		return s.previous
 	}
-	return time.Since(s.startTime)
+	return s.previous + s.clock.Now().Sub(s.startTime)
 }
 
 // view returns the UI string of stopwatch.
@@ -41,7 +58,23 @@ func (s *stopwatch) view() string {
 	return s.elapsed().Round(time.Millisecond * 100).String()
 }
 
-// newStopwatch returns a new instance of stopwatch.
-func newStopwatch() stopwatch {
-	return stopwatch{}
+// timedOut tells if the timeout has elapsed, if set.
+func (s *stopwatch) timedOut() bool {
+	return s.timeout > 0 && s.elapsed() >= s.timeout
+}
+
+// countdownView returns the UI string of the time left before the
+// timeout, rounded up to the second.
+func (s *stopwatch) countdownView() string {
+	remaining := s.timeout - s.elapsed()
+	if remaining < 0 {
+		remaining = 0
+	}
+	return (remaining + time.Second - 1).Truncate(time.Second).String()
+}
+
+// newStopwatch returns a new instance of stopwatch, timing out after
+// the given duration unless 0.
+func newStopwatch(clock Clock, timeout time.Duration) stopwatch {
+	return stopwatch{clock: clock, timeout: timeout}
 }
@@ -4,94 +4,113 @@ import (
 	"context"
 	"strings"
 	"time"
+	"typechan/engine"
 
 	"github.com/charmbracelet/bubbles/progress"
-	"github.com/charmbracelet/bubbles/timer"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// typingPage is the model for the typing test page.
+// typingPage is the model for the typing test page, a view over the
+// engine's test.
 type typingPage struct {
 	app          *app
 	quoteFetcher *quoteFetcher
+	seed         int64 // the texts of the test are picked with
+	test         *engine.Test
 	started      bool
+	startTime    time.Time // when the first key was pressed
+	paused       bool      // the stopwatch is paused while waiting for more text
+	fetchError   error     // last error from fetching text, if any
 
-	totalKeysPressed   int
-	correctKeysPressed int
+	keyStats *keyStats
 
 	progressBar progress.Model
 	textarea    *textarea
-	wordInput   string
-	stopWatch   stopwatch
-	timer       timer.Model
-
-	currentState State
-	correctState *correctState
-	wrongState   *wrongState
+	texts       []TextInfo // texts added to textarea
+	textOffsets []int      // offset of the start of each text, counted from the start of textarea
+	fallbacks   []bool     // whether each text came from the fallback source
+	stopWatch   stopwatch  // counts down from Timeout in Timed mode
 }
 
 func (t *typingPage) init() error {
-	quotes := []quote{}
-	switch currentMode {
-	case Sprint:
-		q, err := getRandomQuote()
-		if err != nil {
-			return err
-		}
-		quotes = append(quotes, q)
+	keyStats, err := loadKeyStats()
+	if err != nil {
+		return err
+	}
+	t.keyStats = keyStats
+	t.app.sessions.report(sessionStarted, currentMode)
 
-	case Timed:
+	count := 1
+	if currentMode == Timed {
 		// fill up the buffer first
-		for i := 0; i < quoteBufferSize; i++ {
-			q, err := getRandomQuote()
-			if err != nil {
+		count = quoteBufferSize
+	}
+
+	for i := 0; i < count; i++ {
+		q, err := t.quoteFetcher.source.next(t.quoteFetcher.ctx)
+		if err != nil {
+			t.fetchError = err
+			t.app.sessions.report(sessionFetchFailed, currentMode)
+			if q, err = t.quoteFetcher.fallback.next(t.quoteFetcher.ctx); err != nil {
 				return err
 			}
-			quotes = append(quotes, q)
 		}
-		t.quoteFetcher.start(quoteBufferSize)
-	}
-
-	for _, quote := range quotes {
-		t.textarea.append(quote)
+		if err := t.appendText(q, t.fetchError != nil); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// pushWordInput appends a letter to the word input.
-func (t *typingPage) pushWordInput(l string) {
-	t.wordInput += l
+// appendText appends a text to be typed, taken from the fallback source
+// if fallback is true.
+func (t *typingPage) appendText(q quote, fallback bool) error {
+	if err := t.textarea.append(q); err != nil {
+		return err
+	}
+	t.texts = append(t.texts, q.info())
+	t.textOffsets = append(t.textOffsets, t.textarea.Length()-q.length)
+	t.fallbacks = append(t.fallbacks, fallback)
+	return nil
 }
 
-// popWordInput removes the last letter from the word input.
-func (t *typingPage) popWordInput() string {
-	word := []rune(t.wordInput)
-	if len(word) == 0 {
-		return ""
+// typedTexts returns the texts the cursor has reached so far.
+func (t *typingPage) typedTexts() []TextInfo {
+	typed := []TextInfo{}
+	for i, offset := range t.textOffsets {
+		if offset <= t.textarea.Typed() {
+			typed = append(typed, t.texts[i])
+		}
 	}
-	lastLetter := word[len(word)-1]
-	word = word[:len(word)-1] // remove the last letter
-	t.wordInput = string(word)
-	return string(lastLetter)
+	return typed
 }
 
-// clearWordInput clears the word input.
-func (t *typingPage) clearWordInput() {
-	t.wordInput = ""
+// typedFallback tells if any of the texts the cursor has reached so far
+// came from the fallback source.
+func (t *typingPage) typedFallback() bool {
+	for i, offset := range t.textOffsets {
+		if offset <= t.textarea.Typed() && t.fallbacks[i] {
+			return true
+		}
+	}
+	return false
 }
 
-// changeState changes the current state to the given value.
-func (t *typingPage) changeState(s State) {
-	t.currentState = s
+// currentText returns the text the cursor lies in.
+func (t *typingPage) currentText() TextInfo {
+	typed := t.typedTexts()
+	if len(typed) == 0 {
+		return TextInfo{}
+	}
+	return typed[len(typed)-1]
 }
 
-// incrementKeysPressed increments the total number of keys pressed.
-// 'correct' tells whether the current keypress is correct.
-func (t *typingPage) incrementKeysPressed(correct bool) {
-	t.totalKeysPressed++
-	if correct {
-		t.correctKeysPressed++
+// recordKey records a keystroke into the key statistics, if it was a
+// first attempt at a letter.
+func (t *typingPage) recordKey(k engine.Keystroke) {
+	if k.Counted && k.FirstAttempt {
+		t.keyStats.record(k.Previous, k.Expected, k.Correct, k.Latency)
 	}
 }
 
@@ -100,57 +119,106 @@ func (t *typingPage) update(msg tea.Msg) (tea.Cmd, error) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEsc, tea.KeyCtrlC:
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
 			// exit
 			return tea.Quit, nil
-		case tea.KeyBackspace:
-			t.currentState.handleBackspace()
-		case tea.KeySpace:
-			t.currentState.handleSpace()
-		case tea.KeyEnter:
-			t.currentState.handleEnter()
-		case tea.KeyTab, tea.KeyUp, tea.KeyDown, tea.KeyLeft, tea.KeyRight:
-			// do nothing
-		default:
-			t.currentState.handleLetter(msg.String())
+		}
+		if t.paused {
+			// nothing left to type until more text arrives
+			break
+		}
+
+		if t.stopWatch.timedOut() {
+			// time is up, whether the tick telling so has arrived or not
+			return t.toResultPage()
 		}
 
+		key, ok := engineKey(msg)
+		if !ok {
+			break
+		}
 		if !t.started {
 			t.started = true
-			if currentMode == Sprint {
-				cmds = append(cmds, t.stopWatch.start())
+			t.startTime = t.app.clock.Now()
+			cmds = append(cmds, t.stopWatch.start())
+
+			if listener, ok := t.app.source.(startListener); ok {
+				if err := listener.onStart(); err != nil {
+					return nil, err
+				}
 			}
-			if currentMode == Timed {
-				cmds = append(cmds, t.timer.Start())
+		}
+		// keys are timed by the stopwatch, so that the time paused waiting
+		// for text doesn't count when the keystroke log is replayed
+		t.recordKey(t.test.Press(key, t.startTime.Add(t.stopWatch.elapsed())))
+
+		if currentMode == Timed && t.textarea.RemainingLines() < refillLines() {
+			cmds = append(cmds, t.quoteFetcher.fetch())
+		}
+
+		if t.test.Failed() || (currentMode == Sprint && t.textarea.Done()) {
+			cmd, err := t.toResultPage()
+			if err != nil {
+				return nil, err
 			}
+			cmds = append(cmds, cmd)
+		}
+
+		if currentMode == Timed && t.textarea.Done() {
+			// typed faster than text could be fetched
+			t.paused = true
+			t.stopWatch.pause()
 		}
 
-		if currentMode == Timed && len(t.textarea.lines) < scrollTextHeight {
-			t.textarea.append(<-t.quoteFetcher.quotes)
+	case quoteMsg:
+		if msg.fetcher != t.quoteFetcher {
+			// fetched for a previous test
+			break
+		}
+		t.quoteFetcher.received()
+		t.fetchError = msg.err
+		if msg.err != nil {
+			t.app.sessions.report(sessionFetchFailed, currentMode)
+		}
+
+		if msg.quote.length == 0 {
+			// both the source and the fallback failed, try again later
+			cmds = append(cmds, tea.Tick(quoteRetryInterval, func(time.Time) tea.Msg {
+				return refillMsg{}
+			}))
+			break
 		}
 
-		if t.textarea.hasReachedEndOfText() {
-			t.toResultPage()
+		if err := t.appendText(msg.quote, msg.err != nil); err != nil {
+			return nil, err
+		}
+		if t.paused {
+			t.paused = false
+			t.stopWatch.resume()
+		}
+		if t.textarea.RemainingLines() < refillLines() {
+			cmds = append(cmds, t.quoteFetcher.fetch())
 		}
 
+	case refillMsg:
+		cmds = append(cmds, t.quoteFetcher.fetch())
+
 	case TickMsg:
+		if t.stopWatch.timedOut() {
+			cmd, err := t.toResultPage()
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds, cmd)
+			break
+		}
 		cmds = append(cmds, t.stopWatch.tick())
 
-	case timer.TimeoutMsg:
-		t.toResultPage()
-
 	case tea.WindowSizeMsg:
 		t.progressBar.Width = appWidth
 		t.textarea.resize()
 	}
 
-	if currentMode == Timed {
-		var cmd tea.Cmd
-		t.timer, cmd = t.timer.Update(msg)
-		cmds = append(cmds, cmd)
-	}
-
 	return tea.Batch(cmds...), nil
 }
 
@@ -158,67 +226,157 @@ func (t *typingPage) view() string {
 	var progressPercent float64
 	switch currentMode {
 	case Sprint:
-		progressPercent = t.textarea.currentProgress()
+		progressPercent = t.textarea.Progress()
 	case Timed:
-		progressPercent = float64(Timeout-t.timer.Timeout) / float64(Timeout)
+		progressPercent = float64(t.stopWatch.elapsed()) / float64(Timeout)
 	}
-	if t.textarea.anyMistyped() {
+	if t.textarea.AnyMistyped() {
 		t.progressBar.FullColor = string(red)
 	} else {
 		t.progressBar.FullColor = string(green)
 	}
 
 	progressBar := t.progressBar.ViewAs(progressPercent)
-	wordInput := "> " + t.wordInput
+	wordInput := "> " + t.test.WordInput()
 	wordInput = lipgloss.NewStyle().Width(appWidth / 2).Align(lipgloss.Left).Render(wordInput)
 
 	var timeStr string
 	if currentMode == Sprint {
 		timeStr = t.stopWatch.view()
 	} else {
-		timeStr = t.timer.View()
+		timeStr = t.stopWatch.countdownView()
 	}
 	timeStr = lipgloss.NewStyle().Width(appWidth / 2).Align(lipgloss.Right).Render(timeStr)
 
+	status := ""
+	if t.fetchError != nil {
+		status += lipgloss.NewStyle().Foreground(red).Width(paddingX+appWidth).PaddingLeft(paddingX).
+			Render("Couldn't fetch text, using local words instead: "+t.fetchError.Error()) + "\n"
+	}
+	if t.paused {
+		status += strings.Repeat(" ", paddingX) + lipgloss.NewStyle().Foreground(grey).Render("waiting for more text...") + "\n"
+	}
+
+	attribution := ""
+	if credits := t.currentText().attribution(); credits != "" {
+		attribution = strings.Repeat(" ", paddingX) + lipgloss.NewStyle().Foreground(grey).Render(credits) + "\n"
+	}
+
 	return strings.Repeat(" ", paddingX) + progressBar + "\n\n" +
-		t.textarea.View() + "\n\n" +
+		t.textarea.View() + attribution + "\n\n" +
 		strings.Repeat(" ", paddingX) + lipgloss.JoinHorizontal(lipgloss.Top, wordInput, timeStr) + "\n" +
+		status +
 		strings.Repeat(" ", paddingX) + lipgloss.NewStyle().Foreground(grey).Render("esc or ctrl+c to quit")
 
 }
 
+// refillLines returns the number of lines left to type below which more
+// text is fetched in Timed mode, enough to always fill the viewport.
+func refillLines() int {
+	if VisibleLines > minRefillLines {
+		return VisibleLines
+	}
+	return minRefillLines
+}
+
+// refillMsg asks the typing page to fetch more text.
+type refillMsg struct{}
+
+// engineKey returns the key of the engine pressed with the given key
+// message, if any.
+func engineKey(msg tea.KeyMsg) (engine.Key, bool) {
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if msg.Alt {
+			return engine.Key{Kind: engine.DeleteWordKey}, true
+		}
+		return engine.Key{Kind: engine.BackspaceKey}, true
+	case tea.KeyCtrlH, tea.KeyCtrlW:
+		// sent by most terminals for ctrl+backspace
+		return engine.Key{Kind: engine.DeleteWordKey}, true
+	case tea.KeySpace:
+		return engine.Key{Kind: engine.SpaceKey}, true
+	case tea.KeyEnter:
+		return engine.Key{Kind: engine.EnterKey}, true
+	case tea.KeyRunes:
+		if msg.Alt {
+			return engine.Key{}, false
+		}
+		// text pasted comes a letter at a time like typed text, only
+		// much faster: it's told apart by engine.CheckLog
+		letter := msg.Runes[0]
+		if Layout != nil {
+			letter = Layout.translate(letter)
+		}
+		return engine.Key{Kind: engine.LetterKey, Letter: string(letter)}, true
+	}
+	// other keys, e.g. arrows or ctrl combinations, type nothing
+	return engine.Key{}, false
+}
+
 // toResultPage initialises and directs user to the result page.
-func (t *typingPage) toResultPage() error {
+// Returns the command waiting for the result to be sent to the sinks, if any.
+func (t *typingPage) toResultPage() (tea.Cmd, error) {
 	t.quoteFetcher.stop()
 
-	var elapsed time.Duration
-	if currentMode == Sprint {
-		elapsed = t.stopWatch.elapsed()
-	} else {
-		elapsed = Timeout
+	if err := t.keyStats.save(); err != nil {
+		return nil, err
+	}
+
+	totalKeysPressed, correctKeysPressed := t.test.KeysPressed()
+	options := t.test.Options()
+	log := engine.Log(t.test.Keystrokes())
+	resultPage := newResultPage(t.app, Result{
+		Date:               t.app.clock.Now(),
+		Mode:               currentMode.String(),
+		ErrorPolicy:        options.Policy.String(),
+		Layout:             layoutName(),
+		Elapsed:            t.stopWatch.elapsed(),
+		Failed:             t.test.Failed(),
+		Duration:           t.stopWatch.timeout,
+		Texts:              t.typedTexts(),
+		Seed:               t.seed,
+		SkipAhead:          options.SkipAhead,
+		BackspaceWords:     options.BackspaceWords,
+		TotalKeysPressed:   totalKeysPressed,
+		CorrectKeysPressed: correctKeysPressed,
+		UncorrectedErrors:  t.textarea.UncorrectedErrors(),
+		Keystrokes:         engine.Summarize(t.test.Keystrokes()),
+		KeyLog:             log,
+		Flags:              engine.CheckLog(log),
+	})
+	resultPage.fallback = t.typedFallback()
+	if err := t.app.changePage(resultPage); err != nil {
+		return nil, err
+	}
+	if resultPage.sending {
+		return resultPage.waitSent, nil
 	}
-	resultPage := newResultPage(t.app, t.totalKeysPressed, t.correctKeysPressed, elapsed)
-	return t.app.changePage(resultPage)
+	return nil, nil
 }
 
 // newTypingPage returns a new instance of typingPage.
 func newTypingPage(app *app) *typingPage {
 	t := &typingPage{app: app}
-	t.correctState = newCorrectState(t)
-	t.wrongState = newWrongState(t)
-	t.currentState = t.correctState // initially at correct state
+	t.test = engine.NewTest(testOptions())
 
-	t.textarea = newTextarea()
+	t.textarea = newTextarea(t.test.Text())
+	t.textarea.height = VisibleLines
 	t.progressBar = progress.New(progress.WithWidth(appWidth), progress.WithoutPercentage())
 
 	switch currentMode {
 	case Sprint:
-		t.stopWatch = newStopwatch()
+		t.stopWatch = newStopwatch(app.clock, 0)
 	case Timed:
-		t.textarea.scroll = true
-		t.timer = timer.NewWithInterval(Timeout, time.Second)
+		t.stopWatch = newStopwatch(app.clock, Timeout)
 	}
 
-	t.quoteFetcher = newQuoteFetcher(context.Background())
+	if app.seed == 0 && app.firstSeed != 0 {
+		app.seed = app.firstSeed
+	} else {
+		app.seed = nextSeed(app.seed)
+	}
+	t.seed = app.seed
+	t.quoteFetcher = newQuoteFetcher(withTextRand(context.Background(), t.seed), app.source)
 	return t
 }
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"typechan/app"
+
+	"github.com/spf13/cobra"
+)
+
+// dailyCmd launches the daily challenge.
+var dailyCmd = &cobra.Command{
+	Use:   "daily",
+	Short: "Begins the daily challenge",
+	Long: `Begins the daily challenge, whose text is the same for everyone on a
+given date and needs no network. The first attempt started each day is
+the official one, whether it is completed or not, and official attempts
+completed on consecutive days make a streak.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the text must not depend on anyone's filters
+		app.Filter = app.TextFilter{}
+
+		return runTest(app.Sprint, app.NewDailySource())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dailyCmd)
+}
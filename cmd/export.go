@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"typechan/app"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportFile   string
+)
+
+// exportCmd exports the history of results.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the history of results",
+	Long: `Exports the results of all past tests, as JSON Lines (one JSON object
+per result) or CSV, to stdout or to a file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := app.CheckOutputFormat(exportFormat); err != nil {
+			return err
+		}
+
+		w := os.Stdout
+		if exportFile != "" {
+			f, err := os.Create(exportFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return app.ExportHistory(w, exportFormat)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json",
+		"Format of the export, one of: "+strings.Join(app.OutputFormats, ", "))
+	exportCmd.Flags().StringVarP(&exportFile, "file", "o", "", "Write the export to this file rather than stdout")
+	rootCmd.AddCommand(exportCmd)
+}
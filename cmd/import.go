@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"typechan/app"
+
+	"github.com/spf13/cobra"
+)
+
+var importFormat string
+
+// importCmd imports results into the history.
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Imports results into the history",
+	Long: `Imports results into the history, from an export of typechan or of other
+typing tools, such as the CSV export of Monkeytype. Results already in the
+history are skipped. Use - to read from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r := os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		imported, skipped, err := app.ImportHistory(r, importFormat)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d results, skipped %d already in the history\n", imported, skipped)
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "auto",
+		"Format of the file, one of: auto, "+strings.Join(app.ImportFormats, ", "))
+	rootCmd.AddCommand(importCmd)
+}
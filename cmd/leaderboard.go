@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"typechan/app"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	leaderboardAddr  string
+	leaderboardQuery app.LeaderboardQuery
+)
+
+// leaderboardCmd groups the leaderboard commands.
+var leaderboardCmd = &cobra.Command{
+	Use:   "leaderboard",
+	Short: "Serves or shows a team leaderboard",
+	Long: `Serves a leaderboard for a team, which results are submitted to with
+--submit, or shows its top scores.`,
+}
+
+// leaderboardServeCmd runs a leaderboard server.
+var leaderboardServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves a leaderboard",
+	Long: `Serves a leaderboard over HTTP, taking results on POST /results and
+listing the top scores on GET /boards. The metrics of results are checked
+by replaying their keystroke log rather than trusted. Results are kept in
+the data directory. Metrics of the results and of the sessions of clients
+are served on /metrics.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		handler, err := app.NewLeaderboardHandler()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Serving the leaderboard on %s\n", leaderboardAddr)
+		return http.ListenAndServe(leaderboardAddr, handler)
+	},
+}
+
+// leaderboardShowCmd shows the top scores of a leaderboard.
+var leaderboardShowCmd = &cobra.Command{
+	Use:   "show <url>",
+	Short: "Shows the top scores of a leaderboard",
+	Long: `Shows the top scores of the leaderboard served at the given URL, by
+mode and text for sprints, by mode and duration for timed tests.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		boards, err := app.FetchLeaderboard(args[0], leaderboardQuery)
+		if err != nil {
+			return err
+		}
+		if len(boards) == 0 {
+			fmt.Println("No scores yet")
+			return nil
+		}
+		return app.New().ShowLeaderboard(boards)
+	},
+}
+
+func init() {
+	leaderboardServeCmd.Flags().StringVar(&leaderboardAddr, "addr", ":8080", "Address to serve the leaderboard at")
+
+	flags := leaderboardShowCmd.Flags()
+	flags.StringVar(&leaderboardQuery.Mode, "mode", "", "Only show boards of this mode, one of: sprint, timed")
+	flags.DurationVar(&leaderboardQuery.Duration, "duration", 0, "Only show boards of timed tests of this duration e.g. 30s")
+	flags.StringVar(&leaderboardQuery.Text, "text", "", "Only show the board of the text of this ID, as listed by the server")
+
+	leaderboardCmd.AddCommand(leaderboardServeCmd, leaderboardShowCmd)
+	rootCmd.AddCommand(leaderboardCmd)
+}
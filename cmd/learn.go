@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"typechan/app"
+
+	"github.com/spf13/cobra"
+)
+
+var listLessons bool
+
+// learnCmd launches a lesson of the learning curriculum.
+var learnCmd = &cobra.Command{
+	Use:   "learn [lesson]",
+	Short: "Begins a lesson of the touch-typing curriculum",
+	Long: `Begins a lesson of the touch-typing curriculum. Lessons progress from
+the home row to the top and bottom rows, numbers, symbols and capitals, and
+each one is unlocked by reaching the WPM and accuracy required by the one
+before it. Without an argument, the furthest unlocked lesson is taken.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listLessons {
+			return app.ListLessons(os.Stdout)
+		}
+
+		number := 0
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("lesson must be a positive number")
+			}
+			number = n
+		}
+
+		source, err := app.NewLessonSource(number)
+		if err != nil {
+			return err
+		}
+
+		return runTest(app.Sprint, source)
+	},
+}
+
+func init() {
+	learnCmd.Flags().BoolVarP(&listLessons, "list", "l", false, "List the lessons and your progress")
+	rootCmd.AddCommand(learnCmd)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"typechan/app"
+
+	"github.com/spf13/cobra"
+)
+
+// practiceCmd launches an adaptive practice session.
+var practiceCmd = &cobra.Command{
+	Use:   "practice",
+	Short: "Practises the keys you are weakest at",
+	Long: `Begins an adaptive practice session, with text generated to
+over-represent the keys and transitions you mistype or hesitate on the most.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTest(app.Sprint, app.NewAdaptiveSource())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(practiceCmd)
+}
@@ -3,8 +3,20 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"typechan/app"
+	"typechan/engine"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	errorPolicy string
+	difficulty  string
+	output      string
+	resultFile  string
+	layout      string
 )
 
 // rootCmd serves as the entry point to the program.
@@ -12,6 +24,95 @@ var rootCmd = &cobra.Command{
 	Use:   "typechan",
 	Short: "Typechan is a TUI typing test",
 	Long:  `A minimalistic TUI typing test for practising your typing skill.`,
+	// printed by Execute instead
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+
+		policy, err := engine.ParseErrorPolicy(errorPolicy)
+		if err != nil {
+			return err
+		}
+		app.Policy = policy
+		if err := app.CheckPolicy(); err != nil {
+			return err
+		}
+
+		if output != "" {
+			if err := app.CheckOutputFormat(output); err != nil {
+				return err
+			}
+		}
+		if layout != "qwerty" {
+			if app.Layout, err = app.ParseLayout(layout); err != nil {
+				return err
+			}
+		}
+		if err := app.Filter.SetDifficulty(difficulty); err != nil {
+			return err
+		}
+		if err := app.CheckSinks(); err != nil {
+			return err
+		}
+
+		// the flags are fine, errors from here on are not about usage
+		cmd.SilenceUsage = true
+		return nil
+	},
+}
+
+// runTest runs the test in the given mode, taking texts from the given
+// source, then outputs the result of the last test completed if asked to.
+func runTest(m app.Mode, source app.TextSource) error {
+	result, err := app.New().Start(m, source)
+	if err != nil {
+		return err
+	}
+	if result == nil || (output == "" && resultFile == "") {
+		return nil
+	}
+
+	format := output
+	if format == "" {
+		format = "json"
+	}
+
+	w := os.Stdout
+	if resultFile != "" {
+		f, err := os.Create(resultFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return app.WriteResults(w, format, []app.Result{*result})
+}
+
+// applyConfig sets the flags not given on the command line to the
+// values of the config file, if any.
+func applyConfig(cmd *cobra.Command) error {
+	config, err := app.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	var setErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		values, ok := config[f.Name]
+		if !ok || f.Changed || setErr != nil {
+			return
+		}
+		for _, value := range values {
+			if err := f.Value.Set(value); err != nil {
+				setErr = fmt.Errorf("invalid config value for %s: %w", f.Name, err)
+				return
+			}
+		}
+	})
+	return setErr
 }
 
 func Execute() {
@@ -20,3 +121,34 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVarP(&errorPolicy, "errors", "e", app.Policy.String(),
+		"How mistypes are handled, one of: "+strings.Join(engine.ErrorPolicyNames(), ", "))
+	flags.StringVar(&app.QuotableURL, "quotes-url", app.QuotableURL, "Base URL of the quotable API")
+	flags.StringVar(&app.Proxy, "proxy", app.Proxy, "Proxy URL for requests, defaults to the HTTP(S)_PROXY environment variables")
+	flags.StringVar(&app.CAFile, "ca-file", app.CAFile, "PEM file of additional certificate authorities to trust")
+	flags.DurationVar(&app.HTTPTimeout, "http-timeout", app.HTTPTimeout, "Time limit of a single request e.g. 5s")
+	flags.BoolVar(&app.SkipAhead, "skip-ahead", app.SkipAhead,
+		"Space always jumps to the next word, leaving unfinished words as errors")
+	flags.BoolVar(&app.BackspaceWords, "backspace-words", app.BackspaceWords,
+		"Allow backspacing into previous words")
+
+	flags.StringVar(&layout, "layout", "qwerty",
+		"Keyboard layout to type in on a QWERTY keyboard, one of: "+strings.Join(app.LayoutNames(), ", "))
+	flags.IntVar(&app.VisibleLines, "lines", app.VisibleLines, "Number of lines of text visible at once, 0 for all")
+	flags.IntVar(&app.Filter.MinLength, "min-length", app.Filter.MinLength, "Minimum length of texts")
+	flags.IntVar(&app.Filter.MaxLength, "max-length", app.Filter.MaxLength, "Maximum length of texts, 0 for no limit")
+	flags.StringSliceVar(&app.Filter.Authors, "author", app.Filter.Authors, "Only take quotes by these authors")
+	flags.StringSliceVar(&app.Filter.Tags, "tag", app.Filter.Tags, "Only take quotes with any of these tags")
+	flags.Int64Var(&app.Seed, "seed", app.Seed, "Seed texts are picked at random with, to get the same texts again, random if 0")
+	flags.StringVar(&difficulty, "difficulty", "any", "Difficulty of texts, one of: any, easy, medium, hard")
+	flags.StringVar(&output, "output", "",
+		"Output the result of the last test on exit, one of: "+strings.Join(app.OutputFormats, ", "))
+	flags.StringVar(&resultFile, "result-file", "", "Write the result of the last test to this file rather than stdout")
+	flags.StringVar(&app.SubmitURL, "submit", app.SubmitURL, "Submit the result of every test completed to the leaderboard at this URL")
+	flags.StringVar(&app.SubmitName, "name", app.SubmitName, "Name to submit results under, defaults to your login name")
+	flags.StringArrayVar(&app.Sinks, "sink", app.Sinks,
+		"Send the result of every test completed to a sink, one of: webhook=URL, file=PATH, command=CMD")
+}
@@ -11,9 +11,13 @@ var sprintCmd = &cobra.Command{
 	Use:   "sprint",
 	Short: "Begins the test in sprint mode",
 	Long:  `Begins the test in sprint mode.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		a := app.New()
-		a.Start(app.Sprint)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := app.NewQuotableSource()
+		if err != nil {
+			return err
+		}
+
+		return runTest(app.Sprint, source)
 	},
 }
 
@@ -17,9 +17,22 @@ var timedCmd = &cobra.Command{
 			return fmt.Errorf("timeout must be larger than 0")
 		}
 
-		a := app.New()
-		a.Start(app.Timed)
-		return nil
+		if app.SubmitURL != "" {
+			if err := app.CheckLeaderboardDuration(app.Timeout); err != nil {
+				return err
+			}
+			// the server picks the texts again from the seed of the test,
+			// so they must not depend on anyone's filters
+			app.Filter = app.TextFilter{}
+			return runTest(app.Timed, app.NewLeaderboardSource())
+		}
+
+		source, err := app.NewQuotableSource()
+		if err != nil {
+			return err
+		}
+
+		return runTest(app.Timed, source)
 	},
 }
 
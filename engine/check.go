@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Front ends which can't tell text pasted from typed, e.g. terminals
+// without bracketed paste, get its keys in a burst, much closer to one
+// another than any typed by hand.
+const pasteInterval time.Duration = 2 * time.Millisecond
+const pasteMinLength int = 5 // keys in a burst
+
+// Intervals between keys shorter than inhumanInterval happen when keys
+// roll over, but only now and then when typing by hand.
+const inhumanInterval time.Duration = 10 * time.Millisecond
+const inhumanShare float64 = 0.25 // of intervals, above which keys are pressed too fast
+const inhumanMinCount int = 10
+
+// MaxWPM is the fastest speed keys are typed at by hand, sustained over
+// sustainedWindow keys by CheckLog.
+const MaxWPM float64 = 350
+const sustainedWindow int = 100 // keys over which speed is sustained
+
+const minRegularCV float64 = 0.05 // coefficient of variation of intervals below which they're too regular
+const regularMinLength int = 30   // intervals needed to tell
+
+// CheckLog tells the reasons to doubt that a keystroke log was typed by
+// hand, if any: pasted text, keys pressed faster than humanly possible
+// or at machine-regular intervals, and impossible sequences of keys.
+func CheckLog(log []LoggedKey) []string {
+	reasons := []string{}
+
+	for i, k := range log {
+		if i == 0 && k.Time != 0 {
+			return append(reasons, "impossible keys: log doesn't start at the first key")
+		}
+		if i > 0 && k.Time < log[i-1].Time {
+			return append(reasons, fmt.Sprintf("impossible keys: key %d pressed before the one before it", i))
+		}
+		if _, ok := keyKindNames[k.Key.Kind]; !ok {
+			return append(reasons, fmt.Sprintf("impossible keys: key %d of unknown kind", i))
+		}
+		if k.Key.Kind == LetterKey && !isSingleLetter(k.Key.Letter) {
+			return append(reasons, fmt.Sprintf("impossible keys: key %d types %q at once", i, k.Key.Letter))
+		}
+	}
+
+	pasted := 0
+	inBurst := pasteBursts(log)
+	intervals := []time.Duration{}
+	for i, k := range log {
+		switch {
+		case k.Key.Kind == PasteKey:
+			pasted += utf8.RuneCountInString(k.Key.Letter)
+		case inBurst[i]:
+			pasted++
+		case i > 0 && !inBurst[i-1] && log[i-1].Key.Kind != PasteKey:
+			intervals = append(intervals, k.Time-log[i-1].Time)
+		}
+	}
+	if pasted > 0 {
+		reasons = append(reasons, fmt.Sprintf("text pasted (%d letters)", pasted))
+	}
+
+	fast := 0
+	for _, interval := range intervals {
+		if interval < inhumanInterval {
+			fast++
+		}
+	}
+	if fast >= inhumanMinCount && float64(fast) > inhumanShare*float64(len(intervals)) {
+		reasons = append(reasons, fmt.Sprintf("keys pressed too fast for a human (%d under %v apart)", fast, inhumanInterval))
+	}
+
+	if len(intervals) >= regularMinLength {
+		if mean, cv := variation(intervals); mean > 0 && cv < minRegularCV {
+			reasons = append(reasons, fmt.Sprintf("keys pressed at machine-regular intervals (every %v)", mean.Round(time.Millisecond)))
+		}
+	}
+
+	// the fastest stretch of sustainedWindow keys
+	for i := sustainedWindow; i < len(log); i++ {
+		span := log[i].Time - log[i-sustainedWindow].Time
+		if wpm := float64(sustainedWindow) / 5 / span.Minutes(); span <= 0 || wpm > MaxWPM {
+			reasons = append(reasons, fmt.Sprintf("typed faster than %.0f WPM for %d keys", MaxWPM, sustainedWindow))
+			break
+		}
+	}
+	return reasons
+}
+
+// pasteBursts tells which keys of the log came in bursts of pasted text.
+func pasteBursts(log []LoggedKey) []bool {
+	inBurst := make([]bool, len(log))
+	start := 0
+	for i := 1; i <= len(log); i++ {
+		if i < len(log) && log[i].Time-log[i-1].Time < pasteInterval {
+			continue
+		}
+		// keys from start to i are as close as pasted ones
+		if i-start >= pasteMinLength {
+			for j := start; j < i; j++ {
+				inBurst[j] = true
+			}
+		}
+		start = i
+	}
+	return inBurst
+}
+
+// isSingleLetter tells if the string is a single printable character.
+func isSingleLetter(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && size == len(s) && r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+// variation returns the mean of the intervals and their coefficient of
+// variation, i.e. their standard deviation relative to their mean.
+func variation(intervals []time.Duration) (time.Duration, float64) {
+	var sum float64
+	for _, d := range intervals {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(intervals))
+	if mean == 0 {
+		return 0, 0
+	}
+
+	var squares float64
+	for _, d := range intervals {
+		squares += (float64(d) - mean) * (float64(d) - mean)
+	}
+	return time.Duration(mean), math.Sqrt(squares/float64(len(intervals))) / mean
+}
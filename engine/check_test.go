@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// logOf returns a log of letter keys pressed at the given intervals, the
+// first key being pressed at 0.
+func logOf(intervals ...time.Duration) []LoggedKey {
+	log := []LoggedKey{{Key: Key{Kind: LetterKey, Letter: "a"}}}
+	for _, interval := range intervals {
+		log = append(log, LoggedKey{Time: log[len(log)-1].Time + interval, Key: Key{Kind: LetterKey, Letter: "a"}})
+	}
+	return log
+}
+
+// humanIntervals returns n intervals of around 200ms, as uneven as typed
+// by hand.
+func humanIntervals(n int) []time.Duration {
+	intervals := []time.Duration{}
+	for i := 0; i < n; i++ {
+		intervals = append(intervals, time.Duration(150+(i*37)%110)*time.Millisecond)
+	}
+	return intervals
+}
+
+// repeated returns the interval repeated n times.
+func repeated(interval time.Duration, n int) []time.Duration {
+	intervals := []time.Duration{}
+	for i := 0; i < n; i++ {
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// alternating returns n intervals alternating between a and b.
+func alternating(a, b time.Duration, n int) []time.Duration {
+	intervals := []time.Duration{}
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			intervals = append(intervals, a)
+		} else {
+			intervals = append(intervals, b)
+		}
+	}
+	return intervals
+}
+
+// concat returns the intervals one after the other.
+func concat(intervals ...[]time.Duration) []time.Duration {
+	all := []time.Duration{}
+	for _, i := range intervals {
+		all = append(all, i...)
+	}
+	return all
+}
+
+func TestCheckLog(t *testing.T) {
+	const ms = time.Millisecond
+	tests := []struct {
+		name string
+		log  []LoggedKey
+		want []string
+	}{
+		{
+			name: "typed by hand",
+			log:  logOf(humanIntervals(300)...),
+			want: []string{},
+		},
+		{
+			name: "burst",
+			log:  logOf(concat(humanIntervals(20), repeated(ms, 9), humanIntervals(20))...),
+			want: []string{"text pasted (10 letters)"},
+		},
+		{
+			name: "keys rolling over",
+			log:  logOf(concat(humanIntervals(20), repeated(ms, 3), humanIntervals(20))...),
+			want: []string{},
+		},
+		{
+			name: "paste key",
+			log: append(logOf(humanIntervals(20)...),
+				LoggedKey{Time: time.Hour, Key: Key{Kind: PasteKey, Letter: "pasted"}}),
+			want: []string{"text pasted (6 letters)"},
+		},
+		{
+			name: "too fast",
+			log:  logOf(alternating(5*ms, 200*ms, 40)...),
+			want: []string{"keys pressed too fast for a human (20 under 10ms apart)"},
+		},
+		{
+			name: "fast now and then",
+			log:  logOf(concat(humanIntervals(40), repeated(5*ms, 12), humanIntervals(40))...),
+			want: []string{},
+		},
+		{
+			name: "regular intervals",
+			log:  logOf(repeated(150*ms, 30)...),
+			want: []string{"keys pressed at machine-regular intervals (every 150ms)"},
+		},
+		{
+			name: "too few intervals to tell",
+			log:  logOf(repeated(150*ms, 29)...),
+			want: []string{},
+		},
+		{
+			name: "sustained speed",
+			log:  logOf(alternating(20*ms, 40*ms, 150)...), // 400 WPM
+			want: []string{"typed faster than 350 WPM for 100 keys"},
+		},
+		{
+			name: "sustained speed under the limit",
+			log:  logOf(alternating(30*ms, 40*ms, 150)...), // 343 WPM
+			want: []string{},
+		},
+		{
+			name: "speed not sustained",
+			log:  logOf(concat(humanIntervals(100), alternating(20*ms, 40*ms, 90), humanIntervals(100))...),
+			want: []string{},
+		},
+		{
+			name: "log not starting at 0",
+			log:  []LoggedKey{{Time: ms, Key: Key{Kind: SpaceKey}}},
+			want: []string{"impossible keys: log doesn't start at the first key"},
+		},
+		{
+			name: "keys out of order",
+			log:  append(logOf(humanIntervals(3)...), LoggedKey{Key: Key{Kind: SpaceKey}}),
+			want: []string{"impossible keys: key 4 pressed before the one before it"},
+		},
+		{
+			name: "unknown kind",
+			log:  append(logOf(humanIntervals(3)...), LoggedKey{Time: time.Hour, Key: Key{Kind: 42}}),
+			want: []string{"impossible keys: key 4 of unknown kind"},
+		},
+		{
+			name: "several letters at once",
+			log:  append(logOf(humanIntervals(3)...), LoggedKey{Time: time.Hour, Key: Key{Kind: LetterKey, Letter: "ab"}}),
+			want: []string{`impossible keys: key 4 types "ab" at once`},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := CheckLog(tt.log); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import "time"
+
+// LoggedKey is a key pressed during a test, as kept in its keystroke log.
+type LoggedKey struct {
+	Time time.Duration `json:"t"` // since the first key pressed
+	Key  Key           `json:"key"`
+}
+
+// Log returns the keystroke log of the given keystrokes, enough to
+// replay the test.
+func Log(keystrokes []Keystroke) []LoggedKey {
+	log := []LoggedKey{}
+	for _, k := range keystrokes {
+		log = append(log, LoggedKey{Time: k.Time.Sub(keystrokes[0].Time), Key: k.Key})
+	}
+	return log
+}
+
+// Replay replays a keystroke log onto a new test with the given options,
+// on the given texts appended one after the other, and returns the test
+// as it is after the last key. Fails if a text isn't ASCII.
+func Replay(o Options, texts []string, log []LoggedKey) (*Test, error) {
+	t := NewTest(o)
+	for _, text := range texts {
+		if _, err := t.text.Append(text); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Time{}
+	for _, k := range log {
+		if t.Done() {
+			break
+		}
+		t.Press(k.Key, start.Add(k.Time))
+	}
+	return t, nil
+}
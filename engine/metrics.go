@@ -0,0 +1,59 @@
+package engine
+
+import "time"
+
+// Metrics are the speed and accuracy metrics of a test.
+type Metrics struct {
+	GrossWPM    float64 `json:"grossWPM"`
+	Accuracy    float64 `json:"accuracy"` // range 0 to 1
+	AdjustedWPM float64 `json:"adjustedWPM"`
+	CPM         float64 `json:"cpm"`
+}
+
+// ComputeMetrics computes the metrics of a test from its key counts and
+// elapsed time.
+func ComputeMetrics(totalKeysPressed int, correctKeysPressed int, elapsed time.Duration) Metrics {
+	var m Metrics
+	if totalKeysPressed == 0 {
+		return m
+	}
+	m.Accuracy = float64(correctKeysPressed) / float64(totalKeysPressed)
+
+	if elapsed <= 0 {
+		return m
+	}
+	// https://support.sunburst.com/hc/en-us/articles/229335208-Type-to-Learn-How-are-Words-Per-Minute-and-Accuracy-Calculated-
+	m.GrossWPM = (float64(totalKeysPressed) / 5) / elapsed.Minutes()
+	m.AdjustedWPM = m.GrossWPM * m.Accuracy
+	m.CPM = float64(totalKeysPressed) / elapsed.Minutes()
+	return m
+}
+
+// KeystrokeSummary sums up the keystrokes of a test.
+type KeystrokeSummary struct {
+	Backspaces  int            `json:"backspaces"`       // including words deleted at once
+	MeanLatency time.Duration  `json:"meanLatency"`      // between letters typed correctly in a row
+	Missed      map[string]int `json:"missed,omitempty"` // number of first attempts mistyped, by expected letter
+}
+
+// Summarize sums up the given keystrokes.
+func Summarize(keystrokes []Keystroke) KeystrokeSummary {
+	s := KeystrokeSummary{Missed: map[string]int{}}
+	var totalLatency time.Duration
+	timed := 0
+	for _, k := range keystrokes {
+		switch {
+		case k.Key.Kind == BackspaceKey || k.Key.Kind == DeleteWordKey:
+			s.Backspaces++
+		case k.Counted && k.FirstAttempt && !k.Correct:
+			s.Missed[k.Expected]++
+		case k.Counted && k.FirstAttempt && k.Previous != "":
+			totalLatency += k.Latency
+			timed++
+		}
+	}
+	if timed > 0 {
+		s.MeanLatency = totalLatency / time.Duration(timed)
+	}
+	return s
+}
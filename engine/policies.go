@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorPolicy decides how mistyped keys are handled during a test.
+type ErrorPolicy interface {
+	fmt.Stringer
+
+	// onMistype handles a wrong key pressed while there's no pending mistype.
+	onMistype(t *Test, key string)
+
+	// canStackMistype tells if another wrong key may be stacked on top of
+	// the pending mistypes.
+	canStackMistype(t *Text) bool
+
+	// allowBackspace tells if the user may backspace at all.
+	allowBackspace() bool
+}
+
+// The available error policies.
+var (
+	Lenient      ErrorPolicy = lenientPolicy{}
+	StopOnLetter ErrorPolicy = stopOnLetterPolicy{}
+	StopOnWord   ErrorPolicy = stopOnWordPolicy{}
+	NoBackspace  ErrorPolicy = noBackspacePolicy{}
+	SuddenDeath  ErrorPolicy = suddenDeathPolicy{}
+)
+
+// errorPolicies lists the available error policies.
+var errorPolicies = []ErrorPolicy{Lenient, StopOnLetter, StopOnWord, NoBackspace, SuddenDeath}
+
+// ErrorPolicyNames returns the names of the available error policies.
+func ErrorPolicyNames() []string {
+	names := []string{}
+	for _, p := range errorPolicies {
+		names = append(names, p.String())
+	}
+	return names
+}
+
+// ParseErrorPolicy returns the error policy of the given name.
+func ParseErrorPolicy(name string) (ErrorPolicy, error) {
+	for _, p := range errorPolicies {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown error policy %q, must be one of: %s", name, strings.Join(ErrorPolicyNames(), ", "))
+}
+
+// lenientPolicy allows up to maxMistypedCount wrong letters to be typed
+// ahead of the cursor, after which further input is swallowed until
+// the mistypes are backspaced.
+type lenientPolicy struct{}
+
+func (lenientPolicy) String() string { return "lenient" }
+
+func (lenientPolicy) onMistype(t *Test, key string) {
+	t.text.incrementMistypedCount()
+	t.changeState(t.wrongState)
+}
+
+func (lenientPolicy) canStackMistype(t *Text) bool {
+	return t.canIncrementMistyped()
+}
+
+func (lenientPolicy) allowBackspace() bool { return true }
+
+// stopOnLetterPolicy swallows wrong keys: the cursor doesn't advance
+// until the right key is pressed, and nothing is shown.
+type stopOnLetterPolicy struct{}
+
+func (stopOnLetterPolicy) String() string { return "stop-on-letter" }
+
+func (stopOnLetterPolicy) onMistype(t *Test, key string) {
+	t.popWordInput()
+}
+
+func (stopOnLetterPolicy) canStackMistype(t *Text) bool { return false }
+
+func (stopOnLetterPolicy) allowBackspace() bool { return true }
+
+// stopOnWordPolicy behaves like lenientPolicy, except that mistypes
+// cannot run past the end of the current word, i.e. the word has to be
+// fixed before moving on to the next.
+type stopOnWordPolicy struct{}
+
+func (stopOnWordPolicy) String() string { return "stop-on-word" }
+
+func (stopOnWordPolicy) onMistype(t *Test, key string) {
+	if t.text.remainingWordLength() == 0 {
+		// extra letters at the end of the word are swallowed
+		t.popWordInput()
+		return
+	}
+	t.text.incrementMistypedCount()
+	t.changeState(t.wrongState)
+}
+
+func (stopOnWordPolicy) canStackMistype(t *Text) bool {
+	return t.canIncrementMistyped() && t.mistypedCount < t.remainingWordLength()
+}
+
+func (stopOnWordPolicy) allowBackspace() bool { return true }
+
+// noBackspacePolicy forbids backspacing: wrong keys move the cursor on,
+// leaving the letter marked as an uncorrected error.
+type noBackspacePolicy struct{}
+
+func (noBackspacePolicy) String() string { return "no-backspace" }
+
+func (noBackspacePolicy) onMistype(t *Test, key string) {
+	letter := t.text.currentLetter()
+	t.text.markError(key)
+	t.text.nextLetter()
+	if letter == " " || letter == "\n" {
+		t.clearWordInput()
+	}
+}
+
+func (noBackspacePolicy) canStackMistype(t *Text) bool { return false }
+
+func (noBackspacePolicy) allowBackspace() bool { return false }
+
+// suddenDeathPolicy ends the test on the first mistype.
+type suddenDeathPolicy struct{}
+
+func (suddenDeathPolicy) String() string { return "sudden-death" }
+
+func (suddenDeathPolicy) onMistype(t *Test, key string) {
+	t.failed = true
+}
+
+func (suddenDeathPolicy) canStackMistype(t *Text) bool { return false }
+
+func (suddenDeathPolicy) allowBackspace() bool { return true }
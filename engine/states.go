@@ -0,0 +1,209 @@
+package engine
+
+// State is the interface for all Test states.
+type State interface {
+	handleLetter(string) // handles alphanumerical keys
+	handleSpace()
+	handleBackspace()
+	handleEnter()
+}
+
+// correctState handles the 'correct' behaviour of Test
+// i.e. when there's no mistake in typing.
+type correctState struct {
+	test *Test
+}
+
+// newCorrectState returns a new instance of correctState.
+func newCorrectState(t *Test) *correctState {
+	return &correctState{test: t}
+}
+
+func (s *correctState) handleLetter(l string) {
+	s.test.pushWordInput(l)
+
+	if l == s.test.text.currentLetter() {
+		// correct letter
+		s.test.incrementKeysPressed(true)
+		s.test.text.nextLetter()
+	} else {
+		// wrong letter
+		s.test.incrementKeysPressed(false)
+		s.test.options.Policy.onMistype(s.test, l)
+	}
+}
+
+func (s *correctState) handleSpace() {
+	s.test.pushWordInput(" ")
+
+	if s.test.text.currentLetter() == " " {
+		// correct letter
+		s.test.incrementKeysPressed(true)
+		s.test.clearWordInput()
+		s.test.text.nextLetter()
+	} else {
+		// wrong letter
+		s.test.incrementKeysPressed(false)
+		s.test.options.Policy.onMistype(s.test, " ")
+	}
+}
+
+func (s *correctState) handleBackspace() {
+	poppedLetter := s.test.popWordInput()
+	if poppedLetter != "" {
+		s.test.text.previousLetter()
+	} else {
+		s.test.backToPreviousWord()
+	}
+}
+
+func (s *correctState) handleEnter() {
+	s.test.pushWordInput("⏎")
+
+	if s.test.text.currentLetter() == "\n" {
+		// correct letter
+		s.test.incrementKeysPressed(true)
+		s.test.clearWordInput()
+		s.test.text.nextLetter()
+
+	} else {
+		// wrong letter
+		s.test.incrementKeysPressed(false)
+		s.test.options.Policy.onMistype(s.test, "\n")
+	}
+}
+
+// wrongState handles the 'wrong' behaviour of Test
+// i.e. when there's any mistyped letter.
+type wrongState struct {
+	test *Test
+}
+
+// newWrongState returns a new instance of wrongState.
+func newWrongState(t *Test) *wrongState {
+	return &wrongState{test: t}
+}
+
+func (s *wrongState) handleLetter(l string) {
+	s.test.incrementKeysPressed(false)
+
+	if s.test.options.Policy.canStackMistype(s.test.text) {
+		s.test.pushWordInput(l)
+		s.test.text.incrementMistypedCount()
+	}
+}
+
+func (s *wrongState) handleSpace() {
+	s.test.incrementKeysPressed(false)
+
+	if s.test.options.Policy.canStackMistype(s.test.text) {
+		s.test.pushWordInput(" ")
+		s.test.text.incrementMistypedCount()
+	}
+}
+
+func (s *wrongState) handleBackspace() {
+	poppedLetter := s.test.popWordInput()
+
+	if poppedLetter != "" {
+		if s.test.text.AnyMistyped() {
+			s.test.text.decrementMistypedCount()
+		} else {
+			s.test.text.previousLetter()
+		}
+	}
+
+	if !s.test.text.AnyMistyped() {
+		s.test.changeState(s.test.correctState)
+	}
+}
+
+func (s *wrongState) handleEnter() {
+	s.test.incrementKeysPressed(false)
+
+	if s.test.options.Policy.canStackMistype(s.test.text) {
+		s.test.pushWordInput("⏎")
+		s.test.text.incrementMistypedCount()
+	}
+}
+
+// skipState handles the behaviour of Test in skip-ahead mode,
+// where wrong letters move the cursor on and are marked as errors,
+// letters typed beyond the end of a word are kept as extra letters,
+// and space always jumps to the next word.
+type skipState struct {
+	test *Test
+}
+
+// newSkipState returns a new instance of skipState.
+func newSkipState(t *Test) *skipState {
+	return &skipState{test: t}
+}
+
+func (s *skipState) handleLetter(l string) {
+	text := s.test.text
+
+	if text.remainingWordLength() == 0 {
+		// beyond the end of the word
+		s.test.incrementKeysPressed(false)
+		if text.extrasCount() < maxMistypedCount {
+			s.test.pushWordInput(l)
+			text.addExtra(l)
+		}
+		s.mistype()
+		return
+	}
+
+	s.test.pushWordInput(l)
+	if l == text.currentLetter() {
+		// correct letter
+		s.test.incrementKeysPressed(true)
+	} else {
+		// wrong letter
+		s.test.incrementKeysPressed(false)
+		text.markError(l)
+		s.mistype()
+	}
+	text.nextLetter()
+}
+
+func (s *skipState) handleSpace() {
+	if s.test.wordInput == "" {
+		// nothing typed in the word yet
+		return
+	}
+
+	text := s.test.text
+	correct := text.remainingWordLength() == 0 && !text.currentWordHasErrors()
+	s.test.incrementKeysPressed(correct)
+	if !correct {
+		s.mistype()
+	}
+
+	s.test.clearWordInput()
+	text.skipWord()
+}
+
+func (s *skipState) handleBackspace() {
+	poppedLetter := s.test.popWordInput()
+	if poppedLetter == "" {
+		s.test.backToPreviousWord()
+		return
+	}
+
+	if !s.test.text.removeExtra() {
+		s.test.text.previousLetter()
+	}
+}
+
+func (s *skipState) handleEnter() {
+	// words may be separated by a newline, which is skipped in the same way
+	s.handleSpace()
+}
+
+// mistype ends the test if the error policy does not tolerate mistypes.
+func (s *skipState) mistype() {
+	if _, ok := s.test.options.Policy.(suddenDeathPolicy); ok {
+		s.test.failed = true
+	}
+}
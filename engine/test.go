@@ -0,0 +1,295 @@
+// Package engine implements the typing test itself, i.e. the text model,
+// cursor, error policies, keystroke records and metrics, independently of
+// any user interface.
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxMistypedCount is the maximum number of mistypes stacked on the
+// cursor, and of extra letters typed beyond the end of a word.
+const maxMistypedCount int = 10
+
+// KeyKind is the kind of a key pressed during a test.
+type KeyKind int
+
+const (
+	LetterKey     KeyKind = iota // any key typing a letter
+	SpaceKey                     // space
+	EnterKey                     // enter, typing a newline
+	BackspaceKey                 // backspace, deleting a letter
+	DeleteWordKey                // e.g. ctrl+backspace, deleting a word
+	PasteKey                     // text pasted at once, for front ends telling pastes apart; ignored
+)
+
+// keyKindNames are the names of the key kinds, as they are encoded.
+var keyKindNames = map[KeyKind]string{
+	LetterKey:     "letter",
+	SpaceKey:      "space",
+	EnterKey:      "enter",
+	BackspaceKey:  "backspace",
+	DeleteWordKey: "deleteWord",
+	PasteKey:      "paste",
+}
+
+func (k KeyKind) String() string {
+	if name, ok := keyKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("KeyKind(%d)", int(k))
+}
+
+func (k KeyKind) MarshalText() ([]byte, error) {
+	if _, ok := keyKindNames[k]; !ok {
+		return nil, fmt.Errorf("unknown key kind %d", int(k))
+	}
+	return []byte(k.String()), nil
+}
+
+func (k *KeyKind) UnmarshalText(text []byte) error {
+	for kind, name := range keyKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key kind %q", text)
+}
+
+// Key is a key pressed during a test.
+type Key struct {
+	Kind   KeyKind `json:"kind,omitempty"`
+	Letter string  `json:"letter,omitempty"` // the letter typed, for LetterKey, or the text pasted, for PasteKey
+}
+
+// Keystroke is the record of a key pressed during a test.
+type Keystroke struct {
+	Time     time.Time
+	Key      Key
+	Offset   int    // offset of the cursor when the key was pressed, counted from the start of text
+	Expected string // letter under the cursor when the key was pressed, empty past the end of text
+
+	Counted      bool          // counted into the keys pressed, unlike e.g. backspaces
+	Correct      bool          // the right key was pressed, if counted
+	FirstAttempt bool          // first attempt at the expected letter, rather than while correcting mistypes
+	Previous     string        // letter typed correctly right before, empty after a mistype or a backspace
+	Latency      time.Duration // time since the previous first attempt, if Previous is set
+}
+
+// Options are the rules of a test.
+type Options struct {
+	Policy         ErrorPolicy // Lenient if nil
+	SkipAhead      bool        // space always jumps to the next word
+	BackspaceWords bool        // backspacing into previous words is allowed
+	Width          int         // maximum length of the lines text is wrapped into
+}
+
+// Check tells if the options can be applied together.
+func (o Options) Check() error {
+	switch o.Policy.(type) {
+	case stopOnLetterPolicy, stopOnWordPolicy:
+		// skip-ahead never stops on mistypes
+		if o.SkipAhead {
+			return fmt.Errorf("error policy %s cannot be used with skip-ahead", o.Policy)
+		}
+	}
+	return nil
+}
+
+// Test is the model of a typing test: it takes keys pressed, moves the
+// cursor of the text accordingly, and keeps count of them.
+type Test struct {
+	options Options
+	text    *Text
+	failed  bool // the test was ended early by the error policy
+
+	wordInput string
+
+	totalKeysPressed   int
+	correctKeysPressed int
+	keystrokes         []Keystroke
+	previousKey        string    // last letter typed correctly, empty after a mistype or backspace
+	lastKeyTime        time.Time // time of the last first attempt at a letter
+
+	currentState State
+	correctState *correctState
+	wrongState   *wrongState
+	skipState    *skipState
+}
+
+// NewTest returns a new instance of Test, with the given options.
+func NewTest(o Options) *Test {
+	if o.Policy == nil {
+		o.Policy = Lenient
+	}
+
+	t := &Test{options: o, text: NewText(o.Width)}
+	t.correctState = newCorrectState(t)
+	t.wrongState = newWrongState(t)
+	t.skipState = newSkipState(t)
+	t.currentState = t.correctState // initially at correct state
+	if o.SkipAhead {
+		t.currentState = t.skipState
+	}
+	return t
+}
+
+// Press handles a key pressed at the given time, and returns its record.
+// Keys pressed once the text is fully typed are ignored, and so is text
+// pasted, only recorded.
+func (t *Test) Press(key Key, at time.Time) Keystroke {
+	t.keystrokes = append(t.keystrokes, Keystroke{
+		Time:   at,
+		Key:    key,
+		Offset: t.text.totalTyped,
+	})
+	if t.text.Done() {
+		return t.keystrokes[len(t.keystrokes)-1]
+	}
+	t.keystrokes[len(t.keystrokes)-1].Expected = t.text.currentLetter()
+
+	switch key.Kind {
+	case BackspaceKey:
+		if t.options.Policy.allowBackspace() {
+			t.previousKey = ""
+			t.currentState.handleBackspace()
+		}
+	case DeleteWordKey:
+		if t.options.Policy.allowBackspace() {
+			t.previousKey = ""
+			t.deleteWord()
+		}
+	case SpaceKey:
+		t.currentState.handleSpace()
+	case EnterKey:
+		t.currentState.handleEnter()
+	case PasteKey:
+		// typing tests are about typing
+	default:
+		t.currentState.handleLetter(key.Letter)
+	}
+	return t.keystrokes[len(t.keystrokes)-1]
+}
+
+// Text returns the text being typed.
+func (t *Test) Text() *Text {
+	return t.text
+}
+
+// Options returns the rules of the test.
+func (t *Test) Options() Options {
+	return t.options
+}
+
+// Failed tells if the test was ended early by the error policy.
+func (t *Test) Failed() bool {
+	return t.failed
+}
+
+// Done tells if the test is over, the text being fully typed or the
+// test failed.
+func (t *Test) Done() bool {
+	return t.failed || t.text.Done()
+}
+
+// WordInput returns what was typed for the current word.
+func (t *Test) WordInput() string {
+	return t.wordInput
+}
+
+// KeysPressed returns the total number of keys pressed, and how many of
+// them were correct.
+func (t *Test) KeysPressed() (total int, correct int) {
+	return t.totalKeysPressed, t.correctKeysPressed
+}
+
+// Keystrokes returns the record of every key pressed so far.
+func (t *Test) Keystrokes() []Keystroke {
+	return t.keystrokes
+}
+
+// Metrics returns the metrics of the test, given the time it took.
+func (t *Test) Metrics(elapsed time.Duration) Metrics {
+	return ComputeMetrics(t.totalKeysPressed, t.correctKeysPressed, elapsed)
+}
+
+// pushWordInput appends a letter to the word input.
+func (t *Test) pushWordInput(l string) {
+	t.wordInput += l
+}
+
+// popWordInput removes the last letter from the word input.
+func (t *Test) popWordInput() string {
+	word := []rune(t.wordInput)
+	if len(word) == 0 {
+		return ""
+	}
+	lastLetter := word[len(word)-1]
+	word = word[:len(word)-1] // remove the last letter
+	t.wordInput = string(word)
+	return string(lastLetter)
+}
+
+// clearWordInput clears the word input.
+func (t *Test) clearWordInput() {
+	t.wordInput = ""
+}
+
+// backToPreviousWord moves the cursor back into the previous word if
+// BackspaceWords is enabled, restoring what was typed for it into the
+// word input.
+func (t *Test) backToPreviousWord() {
+	if t.options.BackspaceWords && t.text.backToPreviousWord() {
+		t.wordInput = t.text.typedWord()
+	}
+}
+
+// deleteWord backspaces the whole word typed so far, or the previous
+// word if nothing has been typed in the current one.
+func (t *Test) deleteWord() {
+	if t.wordInput == "" {
+		t.currentState.handleBackspace()
+	}
+	for t.wordInput != "" {
+		t.currentState.handleBackspace()
+	}
+}
+
+// changeState changes the current state to the given value.
+func (t *Test) changeState(s State) {
+	t.currentState = s
+}
+
+// incrementKeysPressed increments the total number of keys pressed.
+// 'correct' tells whether the current keypress is correct.
+func (t *Test) incrementKeysPressed(correct bool) {
+	t.totalKeysPressed++
+	if correct {
+		t.correctKeysPressed++
+	}
+
+	keystroke := &t.keystrokes[len(t.keystrokes)-1]
+	keystroke.Counted = true
+	keystroke.Correct = correct
+
+	// only first attempts at a letter are attributed to it, i.e. keys
+	// pressed while correcting a mistype are not
+	if t.currentState == t.wrongState {
+		t.previousKey = ""
+		return
+	}
+	keystroke.FirstAttempt = true
+	if t.previousKey != "" {
+		keystroke.Previous = t.previousKey
+		keystroke.Latency = keystroke.Time.Sub(t.lastKeyTime)
+	}
+
+	t.lastKeyTime = keystroke.Time
+	if correct {
+		t.previousKey = keystroke.Expected
+	} else {
+		t.previousKey = ""
+	}
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// scripts are typed on "the quick brown fox jumps over the lazy dog" by
+// TestScripts, see pressKeys.
+var scripts = []string{
+	"the quick brown fox jumps over the lazy dog",
+	"teh quick brown fox jumps over the lazy dog",
+	"the quick\b\b\bick brown fox jumps over the lazy dog",
+	"the qu brown fox jumps over the lazy dog",
+	"the quickk brown fox jumps over the lazy dog",
+	"the quick brown \b\bn fox jumps",
+	"the quick brown fox \x17\x17jumps over",
+	"the quixxxx\b\b\b\b\bck brown",
+	"thw quick\x17quick brown fox jumps over the lazy dgo",
+	"the quick brwn fox jumps ovr the lazy dog",
+	"the quick brown fox jumps over the lazy dogs",
+	"xxxxxxxxxxxxxxx\b\b\b\b",
+}
+
+// TestScripts types every script with every error policy and option
+// combination, keeping the outcomes in testdata/scripts.golden. The
+// golden file was first written by the same scripts driving the app
+// before the engine was extracted from it.
+func TestScripts(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	lines := []string{}
+	for _, name := range ErrorPolicyNames() {
+		policy, err := ParseErrorPolicy(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, skipAhead := range []bool{false, true} {
+			for _, backspaceWords := range []bool{false, true} {
+				o := Options{Policy: policy, SkipAhead: skipAhead, BackspaceWords: backspaceWords, Width: 60}
+				if o.Check() != nil {
+					continue
+				}
+
+				for i, script := range scripts {
+					test := NewTest(o)
+					if _, err := test.Text().Append("the quick brown fox jumps over the lazy dog"); err != nil {
+						t.Fatal(err)
+					}
+					// the test ends once failed or done
+					for _, r := range script {
+						if test.Failed() || test.Done() {
+							break
+						}
+						pressKeys(test, start, string(r))
+					}
+
+					finished := test.Failed() || test.Done()
+					total, correct := test.KeysPressed()
+					line := fmt.Sprintf("%s skip=%v words=%v #%d: finished=%v failed=%v keys=%d/%d errors=%d",
+						name, skipAhead, backspaceWords, i, finished, test.Failed(), total, correct, test.Text().UncorrectedErrors())
+					if !finished {
+						line += fmt.Sprintf(" typed=%d mistyped=%d input=%q", test.Text().Typed(), test.Text().Mistyped(), test.WordInput())
+					}
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+
+	checkGolden(t, filepath.Join("testdata", "scripts.golden"), strings.Join(lines, "\n")+"\n")
+}
+
+// checkGolden compares got against the golden file at the given path, or
+// writes it with got if the -update flag is set.
+func checkGolden(t *testing.T, path string, got string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Errorf("%s:%d\n got: %s\nwant: %s", path, i+1, g, w)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LetterKind tells the state of a letter of a Text.
+type LetterKind int
+
+const (
+	UntypedLetter LetterKind = iota
+	TypedLetter
+	ErrorLetter          // typed wrongly and left uncorrected
+	CursorLetter         // current (untyped) letter
+	MistypedCursorLetter // current letter, covered by mistypes
+	MistypedLetter       // untyped letter covered by mistypes
+)
+
+// Text is the model of the text being typed, wrapped into lines. Its
+// letters are indexed by byte, so texts must be ASCII: substitute or
+// drop other characters before appending them. Letters typed, on the
+// other hand, can be any character.
+type Text struct {
+	lines       []string
+	width       int // maximum length of lines, words longer than it are not broken
+	totalLength int
+	totalTyped  int // number of letters typed, which is also the offset of the cursor from the start of text
+
+	// position of the cursor in the wrapped lines, derived from totalTyped
+	// which stays the same however the text is wrapped
+	currentLineIndex   int // index position of current line in text
+	currentLetterIndex int // index position of letter/cursor, counted from the start of current line
+	mistypedCount      int // number of mistyped letters
+
+	errors map[int]string // letters typed wrongly and left uncorrected, keyed by their offset from the start of text
+	extras map[int]string // letters typed beyond the end of a word, keyed by the offset of the whitespace ending it
+
+	lineOffsets []int // offset of the first letter of each line, counted from the start of text
+}
+
+// NewText returns a new instance of Text, wrapping lines at the given width.
+func NewText(width int) *Text {
+	return &Text{
+		lines:  []string{},
+		width:  width,
+		errors: map[int]string{},
+		extras: map[int]string{},
+	}
+}
+
+// Append appends a text after a newline, and returns the index of the
+// first line that changed. Fails if the text isn't ASCII.
+func (t *Text) Append(text string) (int, error) {
+	for i, r := range text {
+		if r >= utf8.RuneSelf {
+			return 0, fmt.Errorf("text must be ASCII, found %q at offset %d", r, i)
+		}
+	}
+
+	// adds a newline character to the end of text
+	if len(t.lines) != 0 {
+		t.lines[len(t.lines)-1] += "\n"
+		t.totalLength++
+	}
+
+	changedFrom := len(t.lines) - 1 // the last line got a newline
+	if changedFrom < 0 {
+		changedFrom = 0
+	}
+	t.lines = append(t.lines, splitTextIntoLines(text, t.width)...)
+	t.totalLength += len(text)
+	t.indexLines()
+
+	// if the cursor had moved past the end of text, it's now on the newline
+	t.locateCursor()
+	return changedFrom, nil
+}
+
+// currentLine returns the current line in text where the cursor lies.
+func (t *Text) currentLine() string {
+	return t.lines[t.currentLineIndex]
+}
+
+// nextLetter moves the cursor to the next letter.
+func (t *Text) nextLetter() {
+	t.currentLetterIndex++
+	if t.currentLetterIndex >= len(t.currentLine()) {
+		// move to next line
+		t.currentLineIndex++
+		t.currentLetterIndex = 0
+	}
+	t.totalTyped++
+}
+
+// previousLetter moves the cursor to the previous letter.
+func (t *Text) previousLetter() {
+	// ignore if cursor is at the start of the line, or if previous letter is a whitespace
+	if t.currentLetterIndex == 0 || string(t.currentLine()[t.currentLetterIndex-1]) == " " {
+		return
+	}
+	t.currentLetterIndex--
+	t.totalTyped--
+	delete(t.errors, t.totalTyped)
+}
+
+// backToPreviousWord moves the cursor from the start of a word back to
+// the whitespace ending the previous word, even across lines. Letters at
+// the end of the previous word that were skipped rather than typed are
+// untyped again. Returns false if there's no previous word.
+func (t *Text) backToPreviousWord() bool {
+	if t.totalTyped == 0 {
+		return false
+	}
+
+	if t.currentLetterIndex == 0 {
+		t.currentLineIndex--
+		t.currentLetterIndex = len(t.currentLine())
+	}
+	t.currentLetterIndex--
+	t.totalTyped--
+	delete(t.errors, t.totalTyped)
+
+	for t.currentLetterIndex > 0 {
+		if typed, ok := t.errors[t.totalTyped-1]; !ok || typed != "" {
+			break
+		}
+		t.previousLetter()
+	}
+	return true
+}
+
+// typedWord returns what was typed in the current word up to the cursor,
+// including any extra letter typed beyond its end.
+func (t *Text) typedWord() string {
+	lineStart := t.totalTyped - t.currentLetterIndex
+	typed := ""
+	for offset := t.wordStartOffset(); offset < t.totalTyped; offset++ {
+		if letter, ok := t.errors[offset]; ok {
+			typed += letter
+		} else {
+			typed += string(t.currentLine()[offset-lineStart])
+		}
+	}
+	return typed + t.extras[t.totalTyped]
+}
+
+// markError marks the letter pointed by the cursor as typed wrongly,
+// with the letter that was typed instead, if any.
+func (t *Text) markError(typed string) {
+	t.errors[t.totalTyped] = typed
+}
+
+// UncorrectedErrors returns the number of letters marked as typed
+// wrongly, including extra letters typed beyond the end of words.
+func (t *Text) UncorrectedErrors() int {
+	count := len(t.errors)
+	for _, extra := range t.extras {
+		count += utf8.RuneCountInString(extra)
+	}
+	return count
+}
+
+// addExtra adds a letter typed beyond the end of the current word.
+func (t *Text) addExtra(l string) {
+	t.extras[t.totalTyped] += l
+}
+
+// removeExtra removes the last extra letter typed beyond the end of the
+// current word, and tells if there was any.
+func (t *Text) removeExtra() bool {
+	extra := t.extras[t.totalTyped]
+	if extra == "" {
+		return false
+	}
+	_, size := utf8.DecodeLastRuneInString(extra)
+	if size == len(extra) {
+		delete(t.extras, t.totalTyped)
+	} else {
+		t.extras[t.totalTyped] = extra[:len(extra)-size]
+	}
+	return true
+}
+
+// extrasCount returns the number of extra letters typed beyond the end of the current word.
+func (t *Text) extrasCount() int {
+	return utf8.RuneCountInString(t.extras[t.totalTyped])
+}
+
+// Extra returns the extra letters typed beyond the end of the word
+// ended by the whitespace at the given offset, if any.
+func (t *Text) Extra(offset int) string {
+	return t.extras[offset]
+}
+
+// wordStartOffset returns the offset of the first letter of the current
+// word, counted from the start of text.
+func (t *Text) wordStartOffset() int {
+	typedInWord := t.currentLine()[:t.currentLetterIndex]
+	start := strings.LastIndexAny(typedInWord, " \n") + 1
+	return t.totalTyped - (t.currentLetterIndex - start)
+}
+
+// currentWordHasErrors tells if any letter typed so far in the current
+// word is wrong, or if extra letters were typed beyond its end.
+func (t *Text) currentWordHasErrors() bool {
+	for offset := t.wordStartOffset(); offset < t.totalTyped; offset++ {
+		if _, ok := t.errors[offset]; ok {
+			return true
+		}
+	}
+	return t.extrasCount() > 0
+}
+
+// skipWord moves the cursor to the start of the next word, marking the
+// letters left untyped in the current word as errors.
+func (t *Text) skipWord() {
+	for i := t.remainingWordLength(); i > 0; i-- {
+		t.markError("")
+		t.nextLetter()
+	}
+	if !t.Done() {
+		// skip the whitespace ending the word
+		t.nextLetter()
+	}
+}
+
+// remainingWordLength returns the number of letters left to type in the
+// current word, excluding the whitespace that ends it.
+func (t *Text) remainingWordLength() int {
+	rest := t.currentLine()[t.currentLetterIndex:]
+	if end := strings.IndexAny(rest, " \n"); end >= 0 {
+		return end
+	}
+	return len(rest)
+}
+
+// incrementMistypedCount increments the number of mistypes made.
+func (t *Text) incrementMistypedCount() {
+	t.mistypedCount++
+}
+
+// decrementMistypedCount decrements the number of mistypes made.
+func (t *Text) decrementMistypedCount() {
+	if t.AnyMistyped() {
+		t.mistypedCount--
+	}
+}
+
+// canIncrementMistyped tells if mistyped count can still be incremented further.
+func (t *Text) canIncrementMistyped() bool {
+	return t.mistypedCount < t.remainingLettersCount() && t.mistypedCount < maxMistypedCount
+}
+
+// AnyMistyped tells if there's any mistypes made.
+func (t *Text) AnyMistyped() bool {
+	return t.mistypedCount > 0
+}
+
+// Mistyped returns the number of mistypes stacked on the cursor.
+func (t *Text) Mistyped() int {
+	return t.mistypedCount
+}
+
+// Typed returns the number of letters typed, i.e. the offset of the
+// cursor from the start of text.
+func (t *Text) Typed() int {
+	return t.totalTyped
+}
+
+// Length returns the length of the whole text.
+func (t *Text) Length() int {
+	return t.totalLength
+}
+
+// remainingLettersCount returns the number of letters left to type.
+func (t *Text) remainingLettersCount() int {
+	return t.totalLength - t.totalTyped
+}
+
+// Done tells if the cursor has moved beyond the whole text, denoting the
+// completion of the typing test.
+func (t *Text) Done() bool {
+	return t.remainingLettersCount() <= 0
+}
+
+// currentLetter returns the letter currently pointed by the cursor.
+func (t *Text) currentLetter() string {
+	return string(t.lines[t.currentLineIndex][t.currentLetterIndex])
+}
+
+// Lines returns the lines the text is wrapped into.
+func (t *Text) Lines() []string {
+	return t.lines
+}
+
+// LineOffset returns the offset of the first letter of the line at the
+// given index, counted from the start of text.
+func (t *Text) LineOffset(lineIndex int) int {
+	return t.lineOffsets[lineIndex]
+}
+
+// CursorLine returns the index of the line the cursor lies on, which is
+// the number of lines once the whole text is typed.
+func (t *Text) CursorLine() int {
+	return t.currentLineIndex
+}
+
+// RemainingLines returns the number of lines from the current line onwards.
+func (t *Text) RemainingLines() int {
+	return len(t.lines) - t.currentLineIndex
+}
+
+// Progress returns the proportion of the text typed, from 0 to 1.
+func (t *Text) Progress() float64 {
+	return float64(t.totalTyped) / float64(t.totalLength)
+}
+
+// LetterKindAt returns the kind of the letter at the given offset,
+// counted from the start of text.
+func (t *Text) LetterKindAt(offset int) LetterKind {
+	switch {
+	case offset < t.totalTyped:
+		if _, ok := t.errors[offset]; ok {
+			return ErrorLetter
+		}
+		return TypedLetter
+	case offset == t.totalTyped && t.AnyMistyped():
+		return MistypedCursorLetter
+	case offset == t.totalTyped:
+		return CursorLetter
+	case offset < t.totalTyped+t.mistypedCount:
+		return MistypedLetter
+	}
+	return UntypedLetter
+}
+
+// LineAt returns the index of the line the given offset, counted from
+// the start of text, lies on. Offsets before the current line are not
+// looked up.
+func (t *Text) LineAt(offset int) int {
+	lineIndex := t.currentLineIndex
+	for lineIndex+1 < len(t.lines) && t.lineOffsets[lineIndex+1] <= offset {
+		lineIndex++
+	}
+	return lineIndex
+}
+
+// indexLines recomputes the offset of each line.
+func (t *Text) indexLines() {
+	t.lineOffsets = t.lineOffsets[:0]
+	offset := 0
+	for _, line := range t.lines {
+		t.lineOffsets = append(t.lineOffsets, offset)
+		offset += len(line)
+	}
+}
+
+// splitTextIntoLines splits a text string into lines, where the length
+// of each line is bounded by the given width.
+func splitTextIntoLines(text string, width int) []string {
+	result := []string{}
+
+	if len(text) == 0 {
+		return result
+	}
+
+	// preserve trailling whitespace or newline after each word
+	wordsSlice := []string{}
+	buf := []rune{}
+	for _, letter := range text {
+		buf = append(buf, letter)
+		if letter == ' ' || letter == '\n' {
+			wordsSlice = append(wordsSlice, string(buf))
+			buf = []rune{}
+		}
+	}
+	if len(buf) > 0 {
+		wordsSlice = append(wordsSlice, string(buf))
+	}
+
+	line := []string{}
+	lineLen := 0
+	for _, word := range wordsSlice {
+		if lineLen != 0 && lineLen+len(word) > width {
+			result = append(result, strings.Join(line, ""))
+			line = []string{}
+			lineLen = 0
+		}
+		line = append(line, word)
+		lineLen += len(word)
+
+		if word[len(word)-1] == '\n' {
+			result = append(result, strings.Join(line, ""))
+			line = []string{}
+			lineLen = 0
+		}
+	}
+	if lineLen != 0 {
+		result = append(result, strings.Join(line, ""))
+	}
+	return result
+}
+
+// Resize re-splits the text into lines of the given width. Since typed
+// letters, errors and extras are all kept by their offset from the start
+// of text, only the cursor's line and letter indices need to be
+// recomputed.
+func (t *Text) Resize(width int) {
+	t.width = width
+	t.lines = splitTextIntoLines(strings.Join(t.lines, ""), width)
+	t.indexLines()
+	t.locateCursor()
+}
+
+// locateCursor sets the line and letter indices of the cursor from its
+// offset from the start of text. Past the end of text, the cursor is put
+// at the start of a line beyond the last one, as nextLetter does.
+func (t *Text) locateCursor() {
+	if len(t.lines) == 0 {
+		t.currentLineIndex, t.currentLetterIndex = 0, 0
+		return
+	}
+
+	// the line is the last one starting at or before the cursor
+	t.currentLineIndex = sort.Search(len(t.lineOffsets), func(i int) bool {
+		return t.lineOffsets[i] > t.totalTyped
+	}) - 1
+	t.currentLetterIndex = t.totalTyped - t.lineOffsets[t.currentLineIndex]
+
+	if t.currentLetterIndex >= len(t.currentLine()) {
+		t.currentLineIndex++
+		t.currentLetterIndex = 0
+	}
+}
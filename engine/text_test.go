@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pressKeys presses the keys of a script, one every 100ms from start:
+// letters are typed as they are, spaces and newlines as the space and
+// enter keys, '\b' as backspace and '\x17' as ctrl+w.
+func pressKeys(t *Test, start time.Time, script string) {
+	for i, r := range []rune(script) {
+		key := Key{Kind: LetterKey, Letter: string(r)}
+		switch r {
+		case ' ':
+			key = Key{Kind: SpaceKey}
+		case '\n':
+			key = Key{Kind: EnterKey}
+		case '\b':
+			key = Key{Kind: BackspaceKey}
+		case '\x17':
+			key = Key{Kind: DeleteWordKey}
+		}
+		t.Press(key, start.Add(time.Duration(i)*100*time.Millisecond))
+	}
+}
+
+// textState is what a Text shows of the typing, which must not depend on
+// how it's wrapped.
+type textState struct {
+	Typed    int
+	Mistyped int
+	Done     bool
+	Letter   string // under the cursor
+	Word     string // typed in the current word
+	Kinds    []LetterKind
+	Extras   map[int]string
+	Errors   int
+}
+
+func stateOf(text *Text) textState {
+	s := textState{
+		Typed:    text.Typed(),
+		Mistyped: text.Mistyped(),
+		Done:     text.Done(),
+		Extras:   map[int]string{},
+		Errors:   text.UncorrectedErrors(),
+	}
+	if !s.Done {
+		s.Letter = text.currentLetter()
+		s.Word = text.typedWord()
+	}
+	for offset := 0; offset < text.Length(); offset++ {
+		s.Kinds = append(s.Kinds, text.LetterKindAt(offset))
+		if extra := text.Extra(offset); extra != "" {
+			s.Extras[offset] = extra
+		}
+	}
+	return s
+}
+
+// checkLines checks that the text is wrapped at its width, with the
+// cursor where its offset says.
+func checkLines(t *testing.T, text *Text, want string) {
+	t.Helper()
+	if got := strings.Join(text.Lines(), ""); got != want {
+		t.Fatalf("got text %q, want %q", got, want)
+	}
+	for i, line := range text.Lines() {
+		if text.LineOffset(i) != len(strings.Join(text.Lines()[:i], "")) {
+			t.Errorf("line %d: got offset %d", i, text.LineOffset(i))
+		}
+		if len(strings.TrimRight(line, " \n")) > text.width && strings.ContainsAny(strings.TrimRight(line, " \n"), " \n") {
+			t.Errorf("line %d %q is longer than %d", i, line, text.width)
+		}
+	}
+
+	if text.Done() {
+		if text.CursorLine() != len(text.Lines()) || text.currentLetterIndex != 0 {
+			t.Errorf("got cursor at line %d, letter %d past the end of %d lines", text.CursorLine(), text.currentLetterIndex, len(text.Lines()))
+		}
+		return
+	}
+	if got := text.LineOffset(text.CursorLine()) + text.currentLetterIndex; got != text.Typed() {
+		t.Errorf("got cursor at offset %d, want %d", got, text.Typed())
+	}
+	if text.currentLetterIndex >= len(text.currentLine()) {
+		t.Errorf("got cursor at letter %d of a line of %d", text.currentLetterIndex, len(text.currentLine()))
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		texts   []string
+		before  string // typed before resizing
+		widths  []int  // resized to, in turn
+		after   string // typed after resizing
+		more    string // text appended after resizing, if any
+	}{
+		{
+			name:   "mid-word",
+			texts:  []string{"the quick brown fox jumps over the lazy dog"},
+			before: "the qui",
+			widths: []int{5, 80, 3, 12},
+			after:  "ck brown fox",
+		},
+		{
+			name:   "at the start of a line",
+			texts:  []string{"the quick brown fox jumps over the lazy dog"},
+			before: "the quick ",
+			widths: []int{9, 10, 16},
+			after:  "brown",
+		},
+		{
+			name:   "mistypes across a line break",
+			texts:  []string{"the quick brown fox jumps over the lazy dog"},
+			before: "the quic" + "xxxxxxx",
+			widths: []int{4, 7, 30, 9},
+			after:  "\b\b\b\b\b\b\bk brown",
+		},
+		{
+			name:    "skip-ahead errors and extras",
+			options: Options{SkipAhead: true},
+			texts:   []string{"the quick brown fox jumps over the lazy dog"},
+			before:  "th qiuckk brwn fx jum",
+			widths:  []int{6, 15, 1, 40},
+			after:   "ps ovr the lazy dog",
+		},
+		{
+			name:    "backspacing into a previous line",
+			options: Options{BackspaceWords: true},
+			texts:   []string{"the quick brown fox jumps over the lazy dog"},
+			before:  "the quick \b\b",
+			widths:  []int{10, 20, 8},
+			after:   "k brown",
+		},
+		{
+			name:   "several texts",
+			texts:  []string{"hello world", "the quick brown fox"},
+			before: "hello world\nthe",
+			widths: []int{5, 13, 50},
+			after:  " quick",
+		},
+		{
+			name:   "past the end in Timed mode",
+			texts:  []string{"hello world"},
+			before: "hello world",
+			widths: []int{4, 20},
+			more:   "the quick brown fox",
+			after:  "\nthe quick",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			// the same keys pressed on a text never resized
+			tt.options.Width = 10
+			reference := NewTest(tt.options)
+			for _, text := range tt.texts {
+				reference.text.Append(text)
+			}
+			pressKeys(reference, start, tt.before)
+
+			test := NewTest(tt.options)
+			for _, text := range tt.texts {
+				test.text.Append(text)
+			}
+			pressKeys(test, start, tt.before)
+			want := stateOf(test.text)
+			full := strings.Join(test.text.Lines(), "")
+
+			for _, width := range tt.widths {
+				test.text.Resize(width)
+				checkLines(t, test.text, full)
+				if got := stateOf(test.text); !reflect.DeepEqual(got, want) {
+					t.Fatalf("width %d: got %+v, want %+v", width, got, want)
+				}
+			}
+
+			if tt.more != "" {
+				reference.text.Append(tt.more)
+				test.text.Append(tt.more)
+				checkLines(t, test.text, full+"\n"+tt.more)
+				if got := test.text.currentLetter(); got != "\n" {
+					t.Errorf("got cursor on %q once text is appended, want the newline", got)
+				}
+			}
+
+			pressKeys(reference, start, tt.after)
+			pressKeys(test, start, tt.after)
+			if got, want := stateOf(test.text), stateOf(reference.text); !reflect.DeepEqual(got, want) {
+				t.Errorf("after resizing: got %+v, want %+v", got, want)
+			}
+			if got, want := test.WordInput(), reference.WordInput(); got != want {
+				t.Errorf("got word input %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAppendNonASCII(t *testing.T) {
+	text := NewText(80)
+	if _, err := text.Append("café au lait"); err == nil {
+		t.Error("got no error appending non-ASCII text")
+	}
+	if text.Length() != 0 || len(text.Lines()) != 0 {
+		t.Errorf("got %q appended", text.Lines())
+	}
+	if _, err := text.Append("cafe au lait"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNonASCIIKeys(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	test := NewTest(Options{SkipAhead: true, Width: 80})
+	test.Text().Append("cafe au lait")
+
+	pressKeys(test, start, "cafeéé")
+	if got := test.Text().UncorrectedErrors(); got != 2 {
+		t.Errorf("got %d errors for 2 extra letters, want 2", got)
+	}
+	pressKeys(test, start, "\b")
+	if got := test.Text().Extra(4); got != "é" {
+		t.Errorf("got extra %q once backspaced, want %q", got, "é")
+	}
+	pressKeys(test, start, "\b au")
+	if got := test.Text().UncorrectedErrors(); got != 0 {
+		t.Errorf("got %d errors once corrected, want 0", got)
+	}
+	if got := test.Text().Typed(); got != 7 {
+		t.Errorf("got %d letters typed, want 7", got)
+	}
+}